@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"yato/lib"
+)
+
+const cacheUsage = "cache stats|prune|clear"
+
+var cacheCommand = Command{
+	Name:     "cache",
+	Usage:    cacheUsage,
+	Summary:  "Inspect or shrink the image and API response caches",
+	Run:      runCache,
+	Complete: completeWords("stats", "prune", "clear"),
+}
+
+func runCache(args []string) error {
+	if len(args) != 1 {
+		return usageError{usage: cacheUsage}
+	}
+
+	cache, err := lib.NewImageCache()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "stats":
+		stats := cache.Stats()
+		fmt.Printf("Directory: %s\n", stats.Dir)
+		fmt.Printf("Entries:   %d\n", stats.Entries)
+		fmt.Printf("Size:      %s of %s\n", formatBytes(stats.Size), formatBytes(stats.MaxSize))
+		fmt.Printf("Max age:   %d days\n", int(stats.MaxAge.Hours()/24))
+	case "prune":
+		removed, freed, err := cache.Prune()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d entries, freed %s\n", removed, formatBytes(freed))
+	case "clear":
+		if err := cache.Clear(); err != nil {
+			return err
+		}
+		fmt.Println("Cache cleared")
+	default:
+		return usageError{usage: cacheUsage, msg: fmt.Sprintf("unknown cache action %q", args[0])}
+	}
+
+	return nil
+}
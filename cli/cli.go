@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"yato/config"
+	"yato/lib"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK     = 0
+	ExitError  = 1
+	ExitUsage  = 2
+	ExitNoAuth = 3
+	ExitQueued = 4
+)
+
+const exitCodesHelp = "Exit codes: 0 success, 1 error, 2 bad usage, 3 not logged in, 4 saved offline and queued"
+
+// Command is a non-interactive subcommand of the yato binary
+type Command struct {
+	Name    string
+	Usage   string
+	Summary string
+	Run     func(args []string) error
+
+	// Hidden commands are left out of the help, completion and man page
+	Hidden bool
+
+	// Flags lists the flags offered by shell completion. Flags that take a
+	// value end with "=".
+	Flags []string
+
+	// Complete returns the shell completion candidates for the argument
+	// following args, as "value" or "value\tdescription"
+	Complete func(args []string) []string
+}
+
+// usageError reports invalid arguments
+type usageError struct {
+	usage string
+	msg   string
+}
+
+func (e usageError) Error() string {
+	if e.msg == "" {
+		return "usage: " + config.AppName + " " + e.usage
+	}
+	return e.msg + "\nusage: " + config.AppName + " " + e.usage
+}
+
+var errNotLoggedIn = fmt.Errorf("not logged in, run `%s login` first", config.AppName)
+
+var commands []Command
+
+func init() {
+	commands = []Command{
+		listCommand,
+		updateCommand,
+		searchCommand,
+		showCommand,
+		whoamiCommand,
+		loginCommand,
+		logoutCommand,
+		exportCommand,
+		importCommand,
+		scheduleCommand,
+		wrappedCommand,
+		cacheCommand,
+		completionCommand,
+		manCommand,
+		completeCommand,
+	}
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code
+func Run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(os.Stdout)
+		return ExitOK
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printUsage(os.Stderr)
+		return ExitUsage
+	}
+
+	err := cmd.Run(args[1:])
+
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(os.Stderr, usageErr)
+		return ExitUsage
+	case errors.Is(err, errNotLoggedIn):
+		fmt.Fprintf(os.Stderr, "%s %s: %s\n", config.AppName, cmd.Name, err)
+		return ExitNoAuth
+	case errors.Is(err, lib.ErrUpdateQueued):
+		fmt.Fprintf(os.Stderr, "%s %s: %s\n", config.AppName, cmd.Name, err)
+		return ExitQueued
+	default:
+		fmt.Fprintf(os.Stderr, "%s %s: %s\n", config.AppName, cmd.Name, err)
+		return ExitError
+	}
+}
+
+func findCommand(name string) *Command {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [command]\n\n", config.AppName)
+	fmt.Fprintln(w, "Run without a command to start the interactive client.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		if !cmd.Hidden {
+			fmt.Fprintf(w, "  %-46s %s\n", cmd.Usage, cmd.Summary)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, exitCodesHelp)
+}
+
+// newFlagSet creates the flag set of a command. Errors are reported by Run
+// and the usage line on -h by parseFlags.
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(config.AppName, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	return fs
+}
+
+// parseFlags parses args allowing flags and positional arguments to be mixed,
+// e.g. `update 5114 --episodes +1`, and returns the positional arguments
+func parseFlags(fs *flag.FlagSet, usage string, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				fmt.Printf("usage: %s %s\n", config.AppName, usage)
+				return nil, err
+			}
+			return nil, usageError{usage: usage, msg: err.Error()}
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// requireLogin fails when there is no MAL access token
+func requireLogin() error {
+	mal := config.GetConfig().MyAnimeList
+	if mal.AccessToken == "" {
+		return errNotLoggedIn
+	}
+
+	// The list mirror and pending updates are stored per user, so look the
+	// user up once if the login predates recording them
+	if mal.UserID == 0 {
+		if user, err := lib.CurrentUser(); err == nil {
+			if err := lib.RememberUser(user.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkMediaType validates the anime|manga argument shared by several commands
+func checkMediaType(mediaType, usage string) error {
+	if mediaType != "anime" && mediaType != "manga" {
+		return usageError{usage: usage, msg: fmt.Sprintf("unknown type %q, expected anime or manga", mediaType)}
+	}
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON, for piping into jq
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// formatBytes renders a byte count with a binary unit suffix
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
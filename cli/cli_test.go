@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		episodes   string
+		json       bool
+	}{
+		{"flags first", []string{"--episodes", "+1", "5114"}, []string{"5114"}, "+1", false},
+		{"flags after the id", []string{"5114", "--episodes", "+1", "--json"}, []string{"5114"}, "+1", true},
+		{"flags between arguments", []string{"anime", "--json", "5114"}, []string{"anime", "5114"}, "", true},
+		{"value with equals", []string{"5114", "--episodes=-2"}, []string{"5114"}, "-2", false},
+		{"negative value", []string{"5114", "--episodes", "-2"}, []string{"5114"}, "-2", false},
+		{"no arguments", nil, nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFlagSet()
+			episodes := fs.String("episodes", "", "")
+			asJSON := fs.Bool("json", false, "")
+
+			positional, err := parseFlags(fs, "update <id>", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(positional, tt.positional) {
+				t.Errorf("positional = %q, want %q", positional, tt.positional)
+			}
+			if *episodes != tt.episodes || *asJSON != tt.json {
+				t.Errorf("--episodes %q --json %v, want %q and %v", *episodes, *asJSON, tt.episodes, tt.json)
+			}
+		})
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	fs := newFlagSet()
+	fs.Bool("json", false, "")
+
+	_, err := parseFlags(fs, "update <id>", []string{"5114", "--nope"})
+	var usage usageError
+	if !errors.As(err, &usage) {
+		t.Errorf("unknown flag returned %v, want a usage error", err)
+	}
+
+	if _, err := parseFlags(newFlagSet(), "update <id>", []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("-h returned %v, want flag.ErrHelp", err)
+	}
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"yato/lib"
+)
+
+const completionUsage = "completion bash|zsh|fish"
+
+var completionCommand = Command{
+	Name:     "completion",
+	Usage:    completionUsage,
+	Summary:  "Print a shell completion script",
+	Run:      runCompletion,
+	Complete: completeWords("bash", "zsh", "fish"),
+}
+
+// completeCommand is called by the completion scripts with the words typed so
+// far, and prints one candidate per line as "value" or "value\tdescription"
+var completeCommand = Command{
+	Name:   "__complete",
+	Hidden: true,
+	Run: func(args []string) error {
+		for _, candidate := range complete(args) {
+			fmt.Println(candidate)
+		}
+		return nil
+	},
+}
+
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return usageError{usage: completionUsage}
+	}
+
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return usageError{usage: completionUsage, msg: fmt.Sprintf("unsupported shell %q", args[0])}
+	}
+
+	fmt.Print(script)
+	return nil
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for yato, load with: source <(yato completion bash)
+_yato() {
+    local IFS=$'\n'
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local line value desc
+    local -a matches=()
+    COMPREPLY=()
+    for line in $(yato __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null); do
+        value="${line%%$'\t'*}"
+        desc=""
+        [[ $line == *$'\t'* ]] && desc="${line#*$'\t'}"
+        # Titles are completed by id or by any part of their name
+        if [[ $value == "$cur"* ]] || [[ -n $cur && $value =~ ^[0-9]+$ && ${desc,,} == *"${cur,,}"* ]]; then
+            matches+=("$line")
+        fi
+    done
+    if (( ${#matches[@]} == 1 )); then
+        COMPREPLY=("${matches[0]%%$'\t'*}")
+        return
+    fi
+    for line in "${matches[@]}"; do
+        value="${line%%$'\t'*}"
+        if [[ $value =~ ^[0-9]+$ && $line == *$'\t'* ]]; then
+            COMPREPLY+=("$value  ${line#*$'\t'}")
+        else
+            COMPREPLY+=("$value")
+        fi
+    done
+}
+complete -o default -F _yato yato
+`,
+	"zsh": `#compdef yato
+# zsh completion for yato, load with: source <(yato completion zsh)
+_yato() {
+    local -a candidates
+    local line
+    for line in "${(@f)$(yato __complete "${(@)words[2,CURRENT-1]}" 2>/dev/null)}"; do
+        [[ -z $line ]] && continue
+        if [[ $line == *$'\t'* ]]; then
+            candidates+=("${line%%$'\t'*}:${${line#*$'\t'}//:/\\:}")
+        else
+            candidates+=("$line")
+        fi
+    done
+    _describe yato candidates
+}
+compdef _yato yato
+`,
+	"fish": `# fish completion for yato, load with: yato completion fish | source
+function __yato_complete
+    set -l tokens (commandline -opc)
+    yato __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c yato -f -a '(__yato_complete)'
+`,
+}
+
+// complete returns the candidates for the word following args
+func complete(args []string) []string {
+	if len(args) == 0 {
+		var candidates []string
+		for _, cmd := range commands {
+			if !cmd.Hidden {
+				candidates = append(candidates, cmd.Name+"\t"+cmd.Summary)
+			}
+		}
+		return candidates
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		return nil
+	}
+	args = args[1:]
+
+	var candidates []string
+	if cmd.Complete != nil {
+		candidates = cmd.Complete(args)
+	}
+
+	// Flags can't follow a flag that is still waiting for its value
+	if len(args) > 0 && cmd.takesValue(args[len(args)-1]) {
+		return candidates
+	}
+	for _, flag := range cmd.Flags {
+		candidates = append(candidates, strings.TrimSuffix(flag, "="))
+	}
+
+	return candidates
+}
+
+// takesValue reports whether arg is one of the command's flags that expects a value
+func (c *Command) takesValue(arg string) bool {
+	for _, flag := range c.Flags {
+		if strings.HasSuffix(flag, "=") && strings.TrimSuffix(flag, "=") == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// completeWords completes the first positional argument from a fixed set
+func completeWords(words ...string) func(args []string) []string {
+	return func(args []string) []string {
+		if len(args) == 0 {
+			return words
+		}
+		return nil
+	}
+}
+
+// completeFlagValue returns the values for the flag that was typed last, if any
+func completeFlagValue(args []string) ([]string, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+
+	switch args[len(args)-1] {
+	case "--type":
+		return []string{"anime", "manga"}, true
+	case "--status":
+		var statuses []string
+		seen := map[string]bool{}
+		for _, status := range append(lib.AnimeStatuses, lib.MangaStatuses...) {
+			if !seen[status] {
+				seen[status] = true
+				statuses = append(statuses, status)
+			}
+		}
+		return statuses, true
+	case "--format":
+		return lib.ExportFormats, true
+	}
+
+	return nil, false
+}
+
+// completeTitle completes the <id> argument of a command with the titles on
+// the user's locally mirrored list, so it works instantly and offline
+func completeTitle(args []string) []string {
+	if values, ok := completeFlagValue(args); ok {
+		return values
+	}
+
+	// Every flag of the commands completing titles takes a value, except --json
+	mediaType := "anime"
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--json":
+		case strings.HasPrefix(args[i], "--type="):
+			mediaType = strings.TrimPrefix(args[i], "--type=")
+		case strings.HasPrefix(args[i], "-"):
+			if args[i] == "--type" && i+1 < len(args) {
+				mediaType = args[i+1]
+			}
+			i++
+		default:
+			// The id was already given
+			return nil
+		}
+	}
+
+	list, err := lib.LoadList(mediaType)
+	if err != nil {
+		return nil
+	}
+
+	candidates := make([]string, 0, len(list.Entries))
+	for _, entry := range list.Entries {
+		candidates = append(candidates, strconv.Itoa(entry.Node.ID)+"\t"+entry.Node.Title)
+	}
+	return candidates
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yato/config"
+)
+
+// mirrorLists writes the list mirror of user 1 to a temporary data dir
+func mirrorLists(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("YATO_DATA_DIR", dir)
+	saved := config.GetConfig().MyAnimeList
+	t.Cleanup(func() { config.GetConfig().MyAnimeList = saved })
+	config.GetConfig().MyAnimeList = config.MyAnimeListConfig{AccessToken: "token", UserID: 1}
+
+	lists := filepath.Join(dir, "users", "1", "lists")
+	if err := os.MkdirAll(lists, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{
+		"anime.json": `{"media_type": "anime", "entries": [{"node": {"id": 5114, "title": "Fullmetal Alchemist: Brotherhood"}}]}`,
+		"manga.json": `{"media_type": "manga", "entries": [{"node": {"id": 2, "title": "Berserk"}}]}`,
+	} {
+		if err := os.WriteFile(filepath.Join(lists, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	mirrorLists(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+		// notWant are prefixes no candidate may start with
+		notWant []string
+	}{
+		{
+			name:    "commands",
+			args:    nil,
+			want:    []string{"update\tUpdate your list entry for a title", "cache\tInspect or shrink the image and API response caches"},
+			notWant: []string{"__complete"},
+		},
+		{
+			name: "subcommand words",
+			args: []string{"cache"},
+			want: []string{"stats", "prune", "clear"},
+		},
+		{
+			name: "titles and flags",
+			args: []string{"update"},
+			want: []string{"5114\tFullmetal Alchemist: Brotherhood", "--episodes", "--json"},
+		},
+		{
+			name:    "titles of the given type",
+			args:    []string{"update", "--type", "manga"},
+			want:    []string{"2\tBerserk"},
+			notWant: []string{"5114"},
+		},
+		{
+			name:    "flag values",
+			args:    []string{"update", "--status"},
+			want:    []string{"watching", "plan_to_read"},
+			notWant: []string{"--", "5114"},
+		},
+		{
+			name:    "only flags after the id",
+			args:    []string{"update", "5114"},
+			want:    []string{"--score"},
+			notWant: []string{"5114"},
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"nope"},
+			notWant: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := complete(tt.args)
+			seen := make(map[string]bool)
+			for _, candidate := range candidates {
+				seen[candidate] = true
+				for _, notWant := range tt.notWant {
+					if strings.HasPrefix(candidate, notWant) {
+						t.Errorf("unexpected candidate %q", candidate)
+					}
+				}
+			}
+			for _, want := range tt.want {
+				if !seen[want] {
+					t.Errorf("missing candidate %q", want)
+				}
+			}
+		})
+	}
+}
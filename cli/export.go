@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"yato/lib"
+)
+
+const exportUsage = "export --format mal-xml|json|csv --type anime|manga [--output FILE]"
+
+var exportCommand = Command{
+	Name:    "export",
+	Usage:   "export --format mal-xml|json|csv --type anime|manga",
+	Summary: "Export your list for backups or MAL's importer",
+	Run:     runExport,
+	Flags:   []string{"--format=", "--type=", "--output="},
+	Complete: func(args []string) []string {
+		values, _ := completeFlagValue(args)
+		return values
+	},
+}
+
+func runExport(args []string) error {
+	fs := newFlagSet()
+	format := fs.String("format", "mal-xml", "mal-xml, json or csv")
+	mediaType := fs.String("type", "anime", "anime or manga")
+	output := fs.String("output", "", "file to write, defaults to stdout")
+
+	positional, err := parseFlags(fs, exportUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usageError{usage: exportUsage}
+	}
+	if !slices.Contains(lib.ExportFormats, *format) {
+		return usageError{usage: exportUsage, msg: fmt.Sprintf("unknown format %q", *format)}
+	}
+	if err := checkMediaType(*mediaType, exportUsage); err != nil {
+		return err
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	// A backup must be complete, so always refetch the whole list
+	list, err := lib.RefreshList(*mediaType)
+	if err != nil {
+		return err
+	}
+
+	user, err := lib.CurrentUser()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := lib.ExportList(w, *format, list, user); err != nil {
+		return err
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d entries to %s\n", len(list.Entries), *output)
+	}
+	return nil
+}
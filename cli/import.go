@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"yato/lib"
+)
+
+const importUsage = "import <file> [--apply] [--delay DURATION] [--no-search]"
+
+var importCommand = Command{
+	Name:    "import",
+	Usage:   importUsage,
+	Summary: "Import a MAL XML, AniList or Kitsu export",
+	Run:     runImport,
+	Flags:   []string{"--apply", "--delay=", "--no-search"},
+}
+
+func runImport(args []string) error {
+	fs := newFlagSet()
+	apply := fs.Bool("apply", false, "apply the changes instead of only showing them")
+	delay := fs.Duration("delay", time.Second, "time between API requests")
+	noSearch := fs.Bool("no-search", false, "skip entries without a MAL ID instead of searching by title")
+
+	positional, err := parseFlags(fs, importUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{usage: importUsage}
+	}
+	if *delay <= 0 {
+		return usageError{usage: importUsage, msg: "delay must be positive"}
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	file := positional[0]
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	entries, format, err := lib.ParseImport(data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Read %d entries from %s export\n", len(entries), format)
+
+	progress, err := lib.LoadImportProgress(file, data)
+	if err != nil {
+		return err
+	}
+
+	// Compare against the complete, current lists
+	lists := map[string]*lib.UserList{}
+	for _, entry := range entries {
+		if lists[entry.MediaType] == nil {
+			if lists[entry.MediaType], err = lib.RefreshList(entry.MediaType); err != nil {
+				return err
+			}
+		}
+	}
+
+	limiter := time.NewTicker(*delay)
+	defer limiter.Stop()
+
+	var planned []lib.ImportEntry
+	var updates []lib.ListUpdate
+	unresolved := 0
+	for _, entry := range entries {
+		// Checked before searching, so resuming doesn't search again for
+		// the entries already applied
+		if progress.IsDone(entry) {
+			continue
+		}
+
+		if entry.MALID == 0 {
+			if *noSearch {
+				fmt.Printf("? %s: no MAL ID\n", entry.Title)
+				unresolved++
+				continue
+			}
+			<-limiter.C
+			match, err := lib.ResolveMALID(entry)
+			if err != nil {
+				fmt.Printf("? %s: %s\n", entry.Title, err)
+				unresolved++
+				continue
+			}
+			// A different title may well be another season or a sequel,
+			// so it is left for the user to check
+			if !match.Exact {
+				fmt.Printf("? %s: closest match is %s (%s %d), skipped\n", entry.Title, match.Title, entry.MediaType, match.ID)
+				unresolved++
+				continue
+			}
+			entry.MALID = match.ID
+		}
+
+		update := lib.PlanImport(entry, lists[entry.MediaType])
+		if update == nil {
+			continue
+		}
+
+		marker := "~"
+		if lists[entry.MediaType].Find(entry.MALID) == nil {
+			marker = "+"
+		}
+		fmt.Printf("%s %s\n", marker, describeUpdate(*update))
+
+		planned = append(planned, entry)
+		updates = append(updates, *update)
+	}
+
+	if !*apply {
+		fmt.Fprintf(os.Stderr, "Dry run: %d changes, %d unresolved. Run again with --apply to apply them.\n", len(updates), unresolved)
+		return nil
+	}
+
+	for i, update := range updates {
+		<-limiter.C
+		if _, err := lib.UpdateListStatus(update); err != nil {
+			return fmt.Errorf("failed to update %s after %d of %d changes, run the import again to resume: %w",
+				update.Title, i, len(updates), err)
+		}
+		if err := progress.MarkDone(planned[i]); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Applied %d changes, %d unresolved\n", len(updates), unresolved)
+
+	for mediaType := range lists {
+		if _, err := lib.RefreshList(mediaType); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to refresh your %s list, it will catch up on the next sync: %s\n", mediaType, err)
+		}
+	}
+	return progress.Finish()
+}
+
+// describeUpdate renders an update with its fields in a stable order
+func describeUpdate(update lib.ListUpdate) string {
+	fields := make([]string, 0, len(update.Fields))
+	for key, value := range update.Fields {
+		fields = append(fields, key+"="+value)
+	}
+	sort.Strings(fields)
+
+	return fmt.Sprintf("%s (%s %d): %s", update.Title, update.MediaType, update.MALID, strings.Join(fields, ", "))
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"yato/lib"
+)
+
+const listUsage = "list anime|manga [--status STATUS] [--json]"
+
+var listCommand = Command{
+	Name:    "list",
+	Usage:   listUsage,
+	Summary: "Print your anime or manga list",
+	Run:     runList,
+	Flags:   []string{"--status=", "--json"},
+	Complete: func(args []string) []string {
+		if values, ok := completeFlagValue(args); ok {
+			return values
+		}
+		return completeWords("anime", "manga")(args)
+	},
+}
+
+func runList(args []string) error {
+	fs := newFlagSet()
+	status := fs.String("status", "", "only show entries with this status, e.g. watching")
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	positional, err := parseFlags(fs, listUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{usage: listUsage}
+	}
+	mediaType := positional[0]
+	if err := checkMediaType(mediaType, listUsage); err != nil {
+		return err
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	// Fall back to the local mirror when MAL can't be reached
+	list, err := lib.SyncList(mediaType)
+	if err != nil {
+		if list == nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "warning: showing local copy, sync failed: %s\n", err)
+	}
+
+	pending, _ := lib.PendingUpdates()
+	entries := list.WithStatus(*status)
+	for i := range entries {
+		entries[i].ListStatus = lib.ApplyPending(pending, mediaType, entries[i].Node.ID, entries[i].ListStatus)
+	}
+
+	if *asJSON {
+		return printJSON(entries)
+	}
+
+	for _, entry := range entries {
+		fmt.Printf("%-7d %-14s %-9s %-3s %s\n", entry.Node.ID, entry.ListStatus.Status,
+			formatProgress(entry.ListStatus.Progress(), entry.Node.Total()), formatScore(entry.ListStatus.Score), entry.Node.Title)
+	}
+
+	return nil
+}
+
+func formatProgress(progress, total int) string {
+	if total == 0 {
+		return fmt.Sprintf("%d/?", progress)
+	}
+	return fmt.Sprintf("%d/%d", progress, total)
+}
+
+func formatScore(score int) string {
+	if score == 0 {
+		return "-"
+	}
+	return fmt.Sprint(score)
+}
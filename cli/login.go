@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"yato/config"
+	"yato/lib"
+)
+
+var loginCommand = Command{
+	Name:    "login",
+	Usage:   "login",
+	Summary: "Log in to MyAnimeList in your browser",
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return usageError{usage: "login"}
+		}
+		if err := Login(); err != nil {
+			return err
+		}
+		fmt.Println("Logged in")
+		return nil
+	},
+}
+
+var logoutCommand = Command{
+	Name:    "logout",
+	Usage:   "logout",
+	Summary: "Forget the stored MyAnimeList tokens and local list data",
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return usageError{usage: "logout"}
+		}
+		if err := Logout(); err != nil {
+			return err
+		}
+		fmt.Println("Logged out")
+		return nil
+	},
+}
+
+// Login runs the OAuth flow: it opens the MAL authorization page in the
+// browser, waits for the redirect to the local callback server and stores
+// the tokens in the config
+func Login() error {
+	codeVerifier, err := lib.GetNewCodeVerifier()
+	if err != nil {
+		return err
+	}
+
+	url := lib.GetOAuthURL(codeVerifier)
+	result := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authenticate", func(w http.ResponseWriter, r *http.Request) {
+		err := handleOAuthCallback(w, r, codeVerifier)
+		select {
+		case result <- err:
+		default:
+		}
+	})
+	server := &http.Server{Addr: ":42069", Handler: mux}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			result <- fmt.Errorf("HTTP server error: %w", err)
+		}
+	}()
+
+	if err := lib.OpenBrowser(url); err != nil {
+		log.Printf("failed to open browser: %v. Visit %s in your browser to authenticate.", err, url)
+	}
+
+	err = <-result
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		log.Printf("failed to shutdown server: %v", err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to authenticate: %w", err)
+	}
+
+	return nil
+}
+
+func handleOAuthCallback(w http.ResponseWriter, r *http.Request, codeVerifier string) error {
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		http.Error(w, "missing code query parameter. user cancelled authentication", http.StatusBadRequest)
+		return fmt.Errorf("user cancelled authentication")
+	}
+
+	malConfig, err := lib.ExchangeToken(code, codeVerifier)
+	if err != nil {
+		http.Error(w, "failed to exchange token", http.StatusInternalServerError)
+		return fmt.Errorf("failed to exchange token: %w", err)
+	}
+
+	config.GetConfig().MyAnimeList = *malConfig
+	if err := config.SaveConfig(); err != nil {
+		http.Error(w, "failed to save config", http.StatusInternalServerError)
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	w.Write([]byte("Authentication successful! You can now close this tab."))
+	return nil
+}
+
+// Logout removes the stored tokens from the config, along with the list
+// mirror, pending updates and cached responses of the user
+func Logout() error {
+	if err := lib.ForgetUser(); err != nil {
+		return err
+	}
+
+	config.GetConfig().MyAnimeList = config.MyAnimeListConfig{}
+	return config.SaveConfig()
+}
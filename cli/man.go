@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"yato/config"
+)
+
+var manCommand = Command{
+	Name:    "man",
+	Usage:   "man",
+	Summary: "Print the man page, e.g. yato man > yato.1",
+	Run: func(args []string) error {
+		if len(args) != 0 {
+			return usageError{usage: "man"}
+		}
+		fmt.Print(manPage())
+		return nil
+	},
+}
+
+// manPage renders the yato(1) man page in roff from the command table, so it
+// never drifts from the actual commands
+func manPage() string {
+	name := config.AppName
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1 \"\" \"%s %s\" \"User Commands\"\n", strings.ToUpper(name), config.PrettyAppName, config.Version)
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- terminal client for MyAnimeList\n", name)
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n.br\n.B %s\n.I command\n[\\fIargs\\fR]\n", name, name)
+	b.WriteString(".SH DESCRIPTION\n")
+	fmt.Fprintf(&b, "Without a command, %s starts the interactive client. ", name)
+	b.WriteString("The commands below are meant for scripts; most accept \\fB\\-\\-json\\fR to print JSON.\n")
+	b.WriteString(".SH COMMANDS\n")
+	for _, cmd := range commands {
+		if cmd.Hidden {
+			continue
+		}
+		fmt.Fprintf(&b, ".TP\n.B %s %s\n%s\n", name, roffEscape(cmd.Usage), roffEscape(cmd.Summary))
+	}
+	b.WriteString(".SH ENVIRONMENT\n")
+	for _, env := range [][2]string{
+		{"YATO_CONFIG_DIR", "Directory holding config.yaml."},
+		{"YATO_CACHE_DIR", "Directory for cached images and API responses."},
+		{"YATO_DATA_DIR", "Directory for the local list mirror and queued updates."},
+		{"MAL_CLIENT_ID, MAL_CLIENT_SECRET", "MyAnimeList API credentials, when not built in."},
+	} {
+		fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", env[0], env[1])
+	}
+	b.WriteString(".SH EXIT STATUS\n")
+	for _, code := range [][2]string{
+		{"0", "Success."},
+		{"1", "The command failed."},
+		{"2", "Invalid arguments."},
+		{"3", "Not logged in."},
+		{"4", "MyAnimeList could not be reached; the update was queued and will be synced later."},
+	} {
+		fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", code[0], code[1])
+	}
+
+	return b.String()
+}
+
+// roffEscape escapes the characters roff would otherwise interpret
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"yato/lib"
+)
+
+const scheduleUsage = "schedule export --ics [--output FILE]"
+
+var scheduleCommand = Command{
+	Name:     "schedule",
+	Usage:    "schedule export --ics",
+	Summary:  "Export the airing schedule of your list as a calendar",
+	Run:      runSchedule,
+	Flags:    []string{"--ics", "--output="},
+	Complete: completeWords("export"),
+}
+
+func runSchedule(args []string) error {
+	fs := newFlagSet()
+	ics := fs.Bool("ics", false, "write an iCalendar feed")
+	output := fs.String("output", "", "file to write, defaults to stdout")
+
+	positional, err := parseFlags(fs, scheduleUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || positional[0] != "export" {
+		return usageError{usage: scheduleUsage}
+	}
+	if !*ics {
+		return usageError{usage: scheduleUsage, msg: "--ics is the only supported format"}
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	// Incremental syncs only refetch entries edited since the last one, so
+	// broadcast times of the others can be up to a day old. Refetch it all.
+	list, err := lib.RefreshList("anime")
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := lib.ExportScheduleICS(w, list, time.Now()); err != nil {
+		return err
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported the airing schedule to %s\n", *output)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"yato/lib"
+)
+
+const searchUsage = "search <query> [--type anime|manga] [--limit N] [--json]"
+
+var searchCommand = Command{
+	Name:    "search",
+	Usage:   "search <query> [--type anime|manga] [--json]",
+	Summary: "Search MyAnimeList",
+	Run:     runSearch,
+	Flags:   []string{"--type=", "--limit=", "--json"},
+	Complete: func(args []string) []string {
+		values, _ := completeFlagValue(args)
+		return values
+	},
+}
+
+func runSearch(args []string) error {
+	fs := newFlagSet()
+	mediaType := fs.String("type", "anime", "anime or manga")
+	limit := fs.Int("limit", 10, "maximum number of results")
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	positional, err := parseFlags(fs, searchUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return usageError{usage: searchUsage}
+	}
+	if err := checkMediaType(*mediaType, searchUsage); err != nil {
+		return err
+	}
+	if *limit < 1 || *limit > 100 {
+		return usageError{usage: searchUsage, msg: "limit must be between 1 and 100"}
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	titles, err := lib.SearchTitles(*mediaType, strings.Join(positional, " "), *limit)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(titles)
+	}
+
+	for _, title := range titles {
+		fmt.Printf("%-7d %-6s %-5s %s\n", title.ID, title.MediaType, formatMean(title.Mean), title.Title)
+	}
+
+	return nil
+}
+
+func formatMean(mean float64) string {
+	if mean == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", mean)
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"yato/lib"
+)
+
+const showUsage = "show <id> [--type anime|manga] [--json]"
+
+var showCommand = Command{
+	Name:     "show",
+	Usage:    showUsage,
+	Summary:  "Show details of a title",
+	Run:      runShow,
+	Flags:    []string{"--type=", "--json"},
+	Complete: completeTitle,
+}
+
+func runShow(args []string) error {
+	fs := newFlagSet()
+	mediaType := fs.String("type", "anime", "anime or manga")
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	positional, err := parseFlags(fs, showUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{usage: showUsage}
+	}
+	malID, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return usageError{usage: showUsage, msg: fmt.Sprintf("invalid id %q", positional[0])}
+	}
+	if err := checkMediaType(*mediaType, showUsage); err != nil {
+		return err
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	title, err := lib.GetTitle(*mediaType, malID)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(title)
+	}
+
+	genres := make([]string, len(title.Genres))
+	for i, genre := range title.Genres {
+		genres[i] = genre.Name
+	}
+
+	fmt.Printf("%s (%d)\n", title.Title, title.ID)
+	fmt.Printf("Type:       %s, %s\n", title.MediaType, title.Status)
+	fmt.Printf("Score:      %s (ranked #%d, popularity #%d)\n", formatMean(title.Mean), title.Rank, title.Popularity)
+	if title.Total() > 0 {
+		fmt.Printf("Length:     %d\n", title.Total())
+	}
+	if len(genres) > 0 {
+		fmt.Printf("Genres:     %s\n", strings.Join(genres, ", "))
+	}
+	if status := title.MyListStatus; status != nil {
+		fmt.Printf("Your list:  %s, %s, score %s\n", status.Status, formatProgress(status.Progress(), title.Total()), formatScore(status.Score))
+	}
+	if title.Synopsis != "" {
+		fmt.Printf("\n%s\n", title.Synopsis)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"yato/lib"
+)
+
+const updateUsage = "update <id> [--type anime|manga] [--episodes N|+N|-N] [--chapters N|+N|-N] [--volumes N|+N|-N] [--score N] [--status STATUS] [--json]"
+
+var updateCommand = Command{
+	Name:     "update",
+	Usage:    "update <id> [--episodes +1] [--score N]",
+	Summary:  "Update your list entry for a title",
+	Run:      runUpdate,
+	Flags:    []string{"--type=", "--episodes=", "--chapters=", "--volumes=", "--score=", "--status=", "--json"},
+	Complete: completeTitle,
+}
+
+func runUpdate(args []string) error {
+	fs := newFlagSet()
+	mediaType := fs.String("type", "anime", "anime or manga")
+	episodes := fs.String("episodes", "", "episodes watched, absolute or relative like +1")
+	chapters := fs.String("chapters", "", "chapters read, absolute or relative like +1")
+	volumes := fs.String("volumes", "", "volumes read, absolute or relative like +1")
+	score := fs.Int("score", -1, "score from 0 to 10")
+	status := fs.String("status", "", "list status, e.g. watching or completed")
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	positional, err := parseFlags(fs, updateUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError{usage: updateUsage}
+	}
+	malID, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return usageError{usage: updateUsage, msg: fmt.Sprintf("invalid id %q", positional[0])}
+	}
+	if err := checkMediaType(*mediaType, updateUsage); err != nil {
+		return err
+	}
+	if *score > 10 {
+		return usageError{usage: updateUsage, msg: "score must be between 0 and 10"}
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	// Relative changes need the current entry. It comes from MAL, as the
+	// mirror may miss changes made elsewhere, and from the mirror offline.
+	current, title := lib.ListStatus{}, ""
+	if t, err := lib.GetTitle(*mediaType, malID); err == nil {
+		title = t.Title
+		if t.MyListStatus != nil {
+			current = *t.MyListStatus
+		}
+	} else if list, err := lib.LoadList(*mediaType); err == nil {
+		if entry := list.Find(malID); entry != nil {
+			current, title = entry.ListStatus, entry.Node.Title
+		}
+	}
+	base := current.UpdatedAt
+	pending, _ := lib.PendingUpdates()
+	current = lib.ApplyPending(pending, *mediaType, malID, current)
+
+	fields := map[string]string{}
+	for _, change := range []struct {
+		value   string
+		field   string
+		current int
+	}{
+		{*episodes, "num_watched_episodes", current.NumEpisodesWatched},
+		{*chapters, "num_chapters_read", current.NumChaptersRead},
+		{*volumes, "num_volumes_read", current.NumVolumesRead},
+	} {
+		if change.value == "" {
+			continue
+		}
+		n, err := parseCount(change.value, change.current)
+		if err != nil {
+			return usageError{usage: updateUsage, msg: err.Error()}
+		}
+		fields[change.field] = strconv.Itoa(n)
+	}
+	if *score >= 0 {
+		fields["score"] = strconv.Itoa(*score)
+	}
+	if *status != "" {
+		fields["status"] = *status
+	}
+	if len(fields) == 0 {
+		return usageError{usage: updateUsage, msg: "nothing to update"}
+	}
+
+	update := lib.ListUpdate{
+		MediaType:     *mediaType,
+		MALID:         malID,
+		Title:         title,
+		Fields:        fields,
+		BaseUpdatedAt: base,
+	}
+
+	result, err := lib.SubmitListUpdate(update)
+	if errors.Is(err, lib.ErrUpdateQueued) {
+		queued := update.Apply(current)
+		if *asJSON {
+			printJSON(queued)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(result)
+	}
+
+	fmt.Printf("Updated %s\n", update)
+	return nil
+}
+
+// parseCount parses an absolute count like "5" or a relative one like "+1"
+// or "-2", applied to current
+func parseCount(value string, current int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid count %q", value)
+	}
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		n += current
+	}
+	return max(0, n), nil
+}
@@ -0,0 +1,32 @@
+package cli
+
+import "testing"
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		value   string
+		current int
+		want    int
+		wantErr bool
+	}{
+		{"5", 3, 5, false},
+		{"0", 3, 0, false},
+		{"+1", 3, 4, false},
+		{"-2", 3, 1, false},
+		// Relative counts don't go below zero
+		{"-5", 3, 0, false},
+		{"one", 3, 0, true},
+		{"", 3, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCount(tt.value, tt.current)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCount(%q, %d) error = %v, want error %v", tt.value, tt.current, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCount(%q, %d) = %d, want %d", tt.value, tt.current, got, tt.want)
+		}
+	}
+}
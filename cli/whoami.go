@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"yato/lib"
+)
+
+const whoamiUsage = "whoami [--json]"
+
+var whoamiCommand = Command{
+	Name:    "whoami",
+	Usage:   whoamiUsage,
+	Summary: "Show the logged in MyAnimeList user",
+	Run:     runWhoami,
+	Flags:   []string{"--json"},
+}
+
+func runWhoami(args []string) error {
+	fs := newFlagSet()
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	positional, err := parseFlags(fs, whoamiUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usageError{usage: whoamiUsage}
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	user, err := lib.CurrentUser()
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(user)
+	}
+
+	fmt.Println(user.Name)
+	return nil
+}
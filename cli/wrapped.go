@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+	"yato/lib"
+)
+
+const wrappedUsage = "wrapped [--year YEAR] [--format markdown|html] [--output FILE]"
+
+var wrappedCommand = Command{
+	Name:    "wrapped",
+	Usage:   "wrapped [--year YEAR] [--format markdown|html]",
+	Summary: "Write your year in review",
+	Run:     runWrapped,
+	Flags:   []string{"--year=", "--format=", "--output="},
+	Complete: func(args []string) []string {
+		if len(args) > 0 && args[len(args)-1] == "--format" {
+			return lib.WrappedFormats
+		}
+		return nil
+	},
+}
+
+func runWrapped(args []string) error {
+	fs := newFlagSet()
+	year := fs.Int("year", time.Now().Year(), "year to review")
+	format := fs.String("format", "markdown", "markdown or html")
+	output := fs.String("output", "", "file to write, defaults to stdout")
+
+	positional, err := parseFlags(fs, wrappedUsage, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usageError{usage: wrappedUsage}
+	}
+	if !slices.Contains(lib.WrappedFormats, *format) {
+		return usageError{usage: wrappedUsage, msg: fmt.Sprintf("unknown format %q", *format)}
+	}
+	if err := requireLogin(); err != nil {
+		return err
+	}
+
+	var lists []*lib.UserList
+	for _, mediaType := range []string{"anime", "manga"} {
+		list, err := lib.SyncList(mediaType)
+		if err != nil {
+			return err
+		}
+		lists = append(lists, list)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := lib.WriteWrapped(w, *format, lib.ComputeWrapped(*year, lists...)); err != nil {
+		return err
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Wrote your %d in review to %s\n", *year, *output)
+	}
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 
 type Config struct {
 	MyAnimeList MyAnimeListConfig `yaml:"myanimelist"`
+	Cache       CacheConfig       `yaml:"cache,omitempty"`
+	Paths       PathsConfig       `yaml:"paths,omitempty"`
 }
 
 type MyAnimeListConfig struct {
@@ -17,17 +19,43 @@ type MyAnimeListConfig struct {
 	AccessToken  string `yaml:"access_token"`
 	RefreshToken string `yaml:"refresh_token"`
 	ExpiresIn    int    `yaml:"expires_in"`
+
+	// UserID is the logged in user, recorded once known so cached
+	// responses outlive the token
+	UserID int `yaml:"user_id,omitempty"`
+}
+
+type CacheConfig struct {
+	MaxSizeMB  int  `yaml:"max_size_mb,omitempty"`
+	MaxAgeDays int  `yaml:"max_age_days,omitempty"`
+	PreferWebP bool `yaml:"prefer_webp,omitempty"`
+}
+
+// GetMaxSizeMB returns the configured image cache size limit or the default
+func (c CacheConfig) GetMaxSizeMB() int {
+	if c.MaxSizeMB > 0 {
+		return c.MaxSizeMB
+	}
+	return DefaultCacheMaxSizeMB
+}
+
+// GetMaxAgeDays returns the configured image cache expiry or the default
+func (c CacheConfig) GetMaxAgeDays() int {
+	if c.MaxAgeDays > 0 {
+		return c.MaxAgeDays
+	}
+	return DefaultCacheMaxAgeDays
 }
 
 var config Config
 
 func LoadConfig() error {
-	configDir, err := os.UserConfigDir()
+	configDir, err := ConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to get user config dir: %w", err)
+		return err
 	}
 
-	configPath := filepath.Join(configDir, AppName, "config.yaml")
+	configPath := filepath.Join(configDir, "config.yaml")
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -45,17 +73,16 @@ func LoadConfig() error {
 }
 
 func SaveConfig() error {
-	configDir, err := os.UserConfigDir()
+	configDir, err := ConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to get user config dir: %w", err)
+		return err
 	}
 
-	appConfigDir := filepath.Join(configDir, AppName)
-	if err := os.MkdirAll(appConfigDir, 0755); err != nil {
+	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config dir: %w", err)
 	}
 
-	configPath := filepath.Join(appConfigDir, "config.yaml")
+	configPath := filepath.Join(configDir, "config.yaml")
 	data, err := yaml.Marshal(&config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
@@ -16,7 +16,9 @@ var (
 	MALRedirectURI  = "http://localhost:42069/authenticate"
 	MALAPIBaseURL   = "https://api.myanimelist.net/v2"
 	JikanAPIBaseURL = "https://api.jikan.moe/v4"
-	ConfigDir, _    = os.UserConfigDir()
+
+	DefaultCacheMaxSizeMB  = 256
+	DefaultCacheMaxAgeDays = 30
 )
 
 // These variables will be set by the linker during build
@@ -42,7 +44,7 @@ func init() {
 	}
 
 	if MALClientID == "" || MALClientSecret == "" {
-		fmt.Println("Warning: Client ID or Secret not set. Please set MAL_CLIENT_ID and MAL_CLIENT_SECRET environment variables or use the build script.")
+		fmt.Fprintln(os.Stderr, "Warning: Client ID or Secret not set. Please set MAL_CLIENT_ID and MAL_CLIENT_SECRET environment variables or use the build script.")
 	}
 }
 
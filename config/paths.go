@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+type PathsConfig struct {
+	CacheDir string `yaml:"cache_dir,omitempty"`
+	DataDir  string `yaml:"data_dir,omitempty"`
+}
+
+// ConfigDir returns the directory holding config.yaml. It can be overridden
+// with YATO_CONFIG_DIR, since the config file can't point to itself.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("YATO_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config dir: %w", err)
+	}
+
+	return filepath.Join(configDir, AppName), nil
+}
+
+// CacheDir returns the directory for data that can be safely thrown away,
+// such as downloaded images. YATO_CACHE_DIR takes precedence over the
+// cache_dir config option, which takes precedence over $XDG_CACHE_HOME/yato.
+func CacheDir() (string, error) {
+	if dir := os.Getenv("YATO_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := config.Paths.CacheDir; dir != "" {
+		return dir, nil
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache dir: %w", err)
+	}
+
+	return filepath.Join(cacheDir, AppName), nil
+}
+
+// DataDir returns the directory for data that must survive a cache clear,
+// such as pending list updates. YATO_DATA_DIR takes precedence over the
+// data_dir config option, which takes precedence over $XDG_DATA_HOME/yato.
+func DataDir() (string, error) {
+	if dir := os.Getenv("YATO_DATA_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := config.Paths.DataDir; dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, AppName), nil
+	}
+
+	// Windows and macOS don't distinguish config and data directories
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user data dir: %w", err)
+		}
+		return filepath.Join(configDir, AppName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user data dir: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "share", AppName), nil
+}
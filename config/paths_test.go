@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDirs(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG directories are only used on Unix")
+	}
+
+	tests := []struct {
+		name              string
+		env               map[string]string
+		paths             PathsConfig
+		cacheDir, dataDir string
+	}{
+		{
+			name:     "XDG defaults",
+			env:      map[string]string{"XDG_CACHE_HOME": "/xdg/cache", "XDG_DATA_HOME": "/xdg/data"},
+			cacheDir: "/xdg/cache/yato",
+			dataDir:  "/xdg/data/yato",
+		},
+		{
+			name:     "home fallback",
+			env:      map[string]string{"HOME": "/home/user"},
+			cacheDir: "/home/user/.cache/yato",
+			dataDir:  "/home/user/.local/share/yato",
+		},
+		{
+			name:     "config option over XDG",
+			env:      map[string]string{"XDG_CACHE_HOME": "/xdg/cache", "XDG_DATA_HOME": "/xdg/data"},
+			paths:    PathsConfig{CacheDir: "/config/cache", DataDir: "/config/data"},
+			cacheDir: "/config/cache",
+			dataDir:  "/config/data",
+		},
+		{
+			name:     "environment over config option",
+			env:      map[string]string{"YATO_CACHE_DIR": "/env/cache", "YATO_DATA_DIR": "/env/data"},
+			paths:    PathsConfig{CacheDir: "/config/cache", DataDir: "/config/data"},
+			cacheDir: "/env/cache",
+			dataDir:  "/env/data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"YATO_CACHE_DIR", "YATO_DATA_DIR", "XDG_CACHE_HOME", "XDG_DATA_HOME"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			saved := config.Paths
+			t.Cleanup(func() { config.Paths = saved })
+			config.Paths = tt.paths
+
+			if dir, err := CacheDir(); err != nil || dir != tt.cacheDir {
+				t.Errorf("CacheDir() = %q, %v, want %q", dir, err, tt.cacheDir)
+			}
+			if dir, err := DataDir(); err != nil || dir != tt.dataDir {
+				t.Errorf("DataDir() = %q, %v, want %q", dir, err, tt.dataDir)
+			}
+		})
+	}
+}
+
+func TestLoadConfigPaths(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("YATO_CONFIG_DIR", dir)
+	t.Setenv("YATO_DATA_DIR", "")
+	saved := config
+	t.Cleanup(func() { config = saved })
+
+	data := []byte("paths:\n  data_dir: /srv/yato\n")
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if dir, err := DataDir(); err != nil || dir != "/srv/yato" {
+		t.Errorf("DataDir() = %q, %v, want the configured /srv/yato", dir, err)
+	}
+}
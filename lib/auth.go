@@ -73,8 +73,7 @@ func ExchangeToken(code, codeVerifier string) (*config.MyAnimeListConfig, error)
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
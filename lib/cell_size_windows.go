@@ -0,0 +1,9 @@
+//go:build windows
+
+package lib
+
+// terminalCellSize returns the size of a single terminal cell in pixels.
+// The Windows console doesn't expose it, so use a common default.
+func terminalCellSize() (int, int) {
+	return defaultCellWidth, defaultCellHeight
+}
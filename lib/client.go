@@ -0,0 +1,153 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"yato/config"
+)
+
+// httpClient is shared by all API requests. The timeout keeps a hung
+// connection from stalling callers that wait on a response.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// newMALRequest creates an authenticated request against the MAL API
+func newMALRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, config.MALAPIBaseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", config.GetConfig().MyAnimeList.AccessToken))
+
+	return req, nil
+}
+
+// newJikanRequest creates a request against the Jikan API
+func newJikanRequest(path string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", config.JikanAPIBaseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	return req, nil
+}
+
+// doJSON sends a request and decodes the JSON response into v, bypassing
+// the response cache
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// fetchJSON sends a GET request and decodes the JSON response into v. Responses
+// are cached on disk: fresh ones are served without a request, stale ones are
+// revalidated with ETag/Last-Modified, and when the server can't be reached
+// the last good response is used and IsOffline starts reporting true.
+func fetchJSON(req *http.Request, v interface{}) error {
+	return fetchJSONMaxAge(req, v, responseTTL(req.URL.String()))
+}
+
+// revalidateJSON is fetchJSON for data that must be current: the cached
+// response is always revalidated, and only used as is while offline
+func revalidateJSON(req *http.Request, v interface{}) error {
+	return fetchJSONMaxAge(req, v, 0)
+}
+
+func fetchJSONMaxAge(req *http.Request, v interface{}, maxAge time.Duration) error {
+	url := req.URL.String()
+	path := responseCachePath(url, responseIdentity(req))
+	cached := loadResponse(path)
+
+	if cached != nil && time.Since(cached.StoredAt) < maxAge {
+		return decodeCachedResponse(cached, v)
+	}
+
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	if strings.HasPrefix(url, config.JikanAPIBaseURL) {
+		waitForJikan()
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if cached != nil && isNetworkError(err) {
+			offline.Store(true)
+			return decodeCachedResponse(cached, v)
+		}
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		offline.Store(false)
+		cached.StoredAt = time.Now()
+		storeResponse(path, cached)
+		return decodeCachedResponse(cached, v)
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError && cached != nil {
+		offline.Store(true)
+		return decodeCachedResponse(cached, v)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	offline.Store(false)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	storeResponse(path, &cachedResponse{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StoredAt:     time.Now(),
+		Body:         body,
+	})
+
+	return nil
+}
+
+func decodeCachedResponse(cached *cachedResponse, v interface{}) error {
+	if err := json.Unmarshal(cached.Body, v); err != nil {
+		return fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return nil
+}
+
+// isNetworkError reports whether err means the server couldn't be reached
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
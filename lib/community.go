@@ -0,0 +1,157 @@
+package lib
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// jikanRequestInterval spaces out consecutive Jikan requests, which are
+// rate limited to three a second
+const jikanRequestInterval = 400 * time.Millisecond
+
+var (
+	jikanMu       sync.Mutex
+	lastJikanSent time.Time
+)
+
+// waitForJikan blocks until a request can be sent to Jikan without going
+// over its rate limit. Responses served from the cache don't count.
+func waitForJikan() {
+	jikanMu.Lock()
+	defer jikanMu.Unlock()
+
+	if wait := jikanRequestInterval - time.Since(lastJikanSent); wait > 0 {
+		time.Sleep(wait)
+	}
+	lastJikanSent = time.Now()
+}
+
+// jikanPage is a page of a Jikan list endpoint
+type jikanPage[T any] struct {
+	Data []T `json:"data"`
+}
+
+// JikanUser is the author of a review
+type JikanUser struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+}
+
+type Review struct {
+	MALId           int        `json:"mal_id"`
+	URL             string     `json:"url"`
+	Date            string     `json:"date"`
+	Review          string     `json:"review"`
+	Score           int        `json:"score"`
+	Tags            []string   `json:"tags"`
+	IsSpoiler       bool       `json:"is_spoiler"`
+	IsPreliminary   bool       `json:"is_preliminary"`
+	EpisodesWatched int        `json:"episodes_watched"`
+	ChaptersRead    int        `json:"chapters_read"`
+	Entry           JikanEntry `json:"entry"`
+	User            JikanUser  `json:"user"`
+}
+
+type NewsItem struct {
+	MALId          int    `json:"mal_id"`
+	URL            string `json:"url"`
+	Title          string `json:"title"`
+	Date           string `json:"date"`
+	AuthorUsername string `json:"author_username"`
+	Comments       int    `json:"comments"`
+	Excerpt        string `json:"excerpt"`
+	// Anime is the title the news was fetched for, filled in by GetListNews
+	Anime string `json:"-"`
+}
+
+type EpisodeRelease struct {
+	Entry    JikanEntry `json:"entry"`
+	Episodes []struct {
+		MALId   int    `json:"mal_id"`
+		URL     string `json:"url"`
+		Title   string `json:"title"`
+		Premium bool   `json:"premium"`
+	} `json:"episodes"`
+	RegionLocked bool `json:"region_locked"`
+}
+
+type Promo struct {
+	Title   string     `json:"title"`
+	Entry   JikanEntry `json:"entry"`
+	Trailer struct {
+		YoutubeID string `json:"youtube_id"`
+		URL       string `json:"url"`
+	} `json:"trailer"`
+}
+
+func fetchJikanList[T any](path string) ([]T, error) {
+	req, err := newJikanRequest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var page jikanPage[T]
+	if err := fetchJSON(req, &page); err != nil {
+		return nil, err
+	}
+
+	return page.Data, nil
+}
+
+// GetRecentReviews fetches the latest anime or manga reviews
+func GetRecentReviews(mediaType string) ([]Review, error) {
+	return fetchJikanList[Review](fmt.Sprintf("/reviews/%s?spoilers=true", mediaType))
+}
+
+// GetAnimeNews fetches the news about an anime
+func GetAnimeNews(malID int) ([]NewsItem, error) {
+	return fetchJikanList[NewsItem](fmt.Sprintf("/anime/%d/news", malID))
+}
+
+// GetRecentEpisodes fetches the episodes recently added to streaming services
+func GetRecentEpisodes() ([]EpisodeRelease, error) {
+	return fetchJikanList[EpisodeRelease]("/watch/episodes")
+}
+
+// GetRecentPromos fetches the latest promotional videos
+func GetRecentPromos() ([]Promo, error) {
+	return fetchJikanList[Promo]("/watch/promos")
+}
+
+// GetListNews fetches the news about the limit anime the user most recently
+// updated while watching, newest first
+func GetListNews(list *UserList, limit int) ([]NewsItem, error) {
+	watching := list.WithStatus("watching")
+	sort.SliceStable(watching, func(i, j int) bool {
+		return watching[i].ListStatus.UpdatedAt > watching[j].ListStatus.UpdatedAt
+	})
+
+	var news []NewsItem
+	for _, entry := range watching[:min(limit, len(watching))] {
+		items, err := GetAnimeNews(entry.Node.ID)
+		if err != nil {
+			return news, err
+		}
+		for _, item := range items {
+			item.Anime = entry.Node.Title
+			news = append(news, item)
+		}
+	}
+
+	// Dates are ISO 8601 with the same offset, so they compare as strings
+	sort.SliceStable(news, func(i, j int) bool {
+		return news[i].Date > news[j].Date
+	})
+
+	return news, nil
+}
+
+var spoilerTag = regexp.MustCompile(`(?is)\[spoiler[^\]]*\].*?\[/spoiler\]`)
+
+// MaskSpoilers hides the [spoiler] sections of a review
+func MaskSpoilers(text string) string {
+	return spoilerTag.ReplaceAllString(text, "[spoiler hidden]")
+}
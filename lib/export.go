@@ -0,0 +1,143 @@
+package lib
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExportFormats lists the formats accepted by ExportList
+var ExportFormats = []string{"mal-xml", "json", "csv"}
+
+// ExportList writes the list to w in the given format. The mal-xml format can
+// be imported back into MAL; user is needed for its header.
+func ExportList(w io.Writer, format string, list *UserList, user *MALUser) error {
+	switch format {
+	case "mal-xml":
+		return exportMALXML(w, list, user)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(list.Entries)
+	case "csv":
+		return exportCSV(w, list)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func exportMALXML(w io.Writer, list *UserList, user *MALUser) error {
+	export := malXMLList{
+		MyInfo: malXMLInfo{
+			UserID:   user.ID,
+			UserName: user.Name,
+		},
+	}
+
+	for _, entry := range list.Entries {
+		status := entry.ListStatus
+		if list.MediaType == "manga" {
+			export.Manga = append(export.Manga, malXMLManga{
+				MangaID:        entry.Node.ID,
+				MangaTitle:     cdata{entry.Node.Title},
+				MangaVolumes:   entry.Node.NumVolumes,
+				MangaChapters:  entry.Node.NumChapters,
+				ReadVolumes:    status.NumVolumesRead,
+				ReadChapters:   status.NumChaptersRead,
+				StartDate:      malXMLDate(status.StartDate),
+				FinishDate:     malXMLDate(status.FinishDate),
+				Score:          status.Score,
+				Status:         malXMLStatuses[status.Status],
+				Comments:       cdata{status.Comments},
+				TimesRead:      status.NumTimesReread,
+				Tags:           cdata{strings.Join(status.Tags, ", ")},
+				Priority:       valueAt(malXMLPriorities, status.Priority),
+				RereadValue:    valueAt(malXMLRepeatValues, status.RereadValue),
+				Rereading:      boolToInt(status.IsRereading),
+				Discuss:        1,
+				SNS:            "default",
+				UpdateOnImport: 1,
+			})
+		} else {
+			export.Anime = append(export.Anime, malXMLAnime{
+				SeriesID:        entry.Node.ID,
+				SeriesTitle:     cdata{entry.Node.Title},
+				SeriesType:      malXMLMediaTypes[entry.Node.MediaType],
+				SeriesEpisodes:  entry.Node.NumEpisodes,
+				WatchedEpisodes: status.NumEpisodesWatched,
+				StartDate:       malXMLDate(status.StartDate),
+				FinishDate:      malXMLDate(status.FinishDate),
+				Score:           status.Score,
+				StorageValue:    "0.00",
+				Status:          malXMLStatuses[status.Status],
+				Comments:        cdata{status.Comments},
+				TimesWatched:    status.NumTimesRewatched,
+				RewatchValue:    valueAt(malXMLRepeatValues, status.RewatchValue),
+				Priority:        valueAt(malXMLPriorities, status.Priority),
+				Tags:            cdata{strings.Join(status.Tags, ", ")},
+				Rewatching:      boolToInt(status.IsRewatching),
+				Discuss:         1,
+				SNS:             "default",
+				UpdateOnImport:  1,
+			})
+		}
+	}
+
+	if list.MediaType == "manga" {
+		export.MyInfo.UserExportType = 2
+		export.MyInfo.TotalManga = len(export.Manga)
+	} else {
+		export.MyInfo.UserExportType = 1
+		export.MyInfo.Total = len(export.Anime)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(export); err != nil {
+		return fmt.Errorf("failed to encode XML: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func exportCSV(w io.Writer, list *UserList) error {
+	progress, total, repeats := "episodes_watched", "num_episodes", "times_rewatched"
+	if list.MediaType == "manga" {
+		progress, total, repeats = "chapters_read", "num_chapters", "times_reread"
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "title", "media_type", "status", "score", progress, total,
+		"start_date", "finish_date", repeats, "tags", "comments", "updated_at"})
+
+	for _, entry := range list.Entries {
+		status := entry.ListStatus
+		writer.Write([]string{
+			strconv.Itoa(entry.Node.ID),
+			entry.Node.Title,
+			entry.Node.MediaType,
+			status.Status,
+			strconv.Itoa(status.Score),
+			strconv.Itoa(status.Progress()),
+			strconv.Itoa(entry.Node.Total()),
+			status.StartDate,
+			status.FinishDate,
+			strconv.Itoa(status.NumTimesRewatched + status.NumTimesReread),
+			strings.Join(status.Tags, ","),
+			status.Comments,
+			status.UpdatedAt,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
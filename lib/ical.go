@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"yato/config"
+)
+
+// defaultEpisodeLength is used for events of titles without an episode duration
+const defaultEpisodeLength = 24 * time.Minute
+
+// ExportScheduleICS writes an iCalendar feed with a weekly recurring event
+// for every airing title on the list that is being watched or planned.
+// Titles without an episode count are skipped. UIDs only depend on the
+// title, so importing the feed again updates the events.
+func ExportScheduleICS(w io.Writer, list *UserList, now time.Time) error {
+	out := bufio.NewWriter(w)
+	line := func(format string, args ...any) {
+		writeICSLine(out, fmt.Sprintf(format, args...))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//%s//%s %s//EN", config.PrettyAppName, config.AppName, config.Version)
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:%s", escapeICS(config.PrettyAppName+" airing schedule"))
+
+	for _, airing := range AiringSchedule(list, now, "watching", "plan_to_watch") {
+		title := airing.Entry.Node
+
+		// Titles without an episode count are left out rather than
+		// repeating forever
+		if title.NumEpisodes == 0 {
+			continue
+		}
+
+		// Without a start date the feed starts at the next episode. How many
+		// have aired isn't known then, but at most the ones the user hasn't
+		// watched are left.
+		start, count := airing.First, title.NumEpisodes
+		if start.IsZero() {
+			start = airing.Next
+			count = title.NumEpisodes - airing.Entry.ListStatus.NumEpisodesWatched
+		}
+		if count <= 0 {
+			continue
+		}
+		rule := fmt.Sprintf("FREQ=WEEKLY;COUNT=%d", count)
+
+		length := time.Duration(title.AverageEpisodeDuration) * time.Second
+		if length == 0 {
+			length = defaultEpisodeLength
+		}
+
+		description := "On your list as " + strings.ReplaceAll(airing.Entry.ListStatus.Status, "_", " ")
+		if title.NumEpisodes > 0 {
+			description += fmt.Sprintf(", %d episodes", title.NumEpisodes)
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:anime-%d@%s", title.ID, config.AppName)
+		line("DTSTAMP:%s", formatICSTime(now))
+		line("DTSTART:%s", formatICSTime(start))
+		line("DURATION:PT%dM", int(length.Minutes()))
+		line("RRULE:%s", rule)
+		line("SUMMARY:%s", escapeICS(title.Title))
+		line("DESCRIPTION:%s", escapeICS(description))
+		line("URL:https://myanimelist.net/anime/%d", title.ID)
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return out.Flush()
+}
+
+func formatICSTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeICS escapes the characters with a meaning in iCalendar text values
+func escapeICS(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line, folding it after 75 octets without
+// splitting UTF-8 sequences, as RFC 5545 requires
+func writeICSLine(w *bufio.Writer, line string) {
+	const limit = 75
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			w.WriteString("\r\n ")
+			width = 1
+		}
+		w.WriteRune(r)
+		width += size
+	}
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,117 @@
+package lib
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscapeICS(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Cowboy Bebop", "Cowboy Bebop"},
+		{"Re:Zero, Part 2; Again", `Re:Zero\, Part 2\; Again`},
+		{`C:\path`, `C:\\path`},
+		{"two\nlines", `two\nlines`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeICS(tt.in); got != tt.want {
+			t.Errorf("escapeICS(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteICSLine(t *testing.T) {
+	tests := []struct {
+		name, line, want string
+	}{
+		{"short", "SUMMARY:Monster", "SUMMARY:Monster\r\n"},
+		{"exactly 75 octets", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"folded", strings.Repeat("a", 80), strings.Repeat("a", 75) + "\r\n " + "aaaaa\r\n"},
+		{"folded twice", strings.Repeat("a", 150), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		// The two octets of é don't fit in the first line
+		{"multibyte", strings.Repeat("a", 74) + "é", strings.Repeat("a", 74) + "\r\n é\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			w := bufio.NewWriter(&out)
+			writeICSLine(w, tt.line)
+			w.Flush()
+			if out.String() != tt.want {
+				t.Errorf("writeICSLine(%q) =\n%q\nwant\n%q", tt.line, out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExportScheduleICS(t *testing.T) {
+	// Three weeks into the broadcast of the titles with a start date
+	now := firstEpisode.AddDate(0, 0, 14).Add(time.Hour)
+	entry := func(id int, name, startDate string, episodes int, status string, watched int) ListEntry {
+		title := airingTitle("currently_airing", startDate, episodes)
+		title.ID, title.Title = id, name
+		return ListEntry{Node: title, ListStatus: ListStatus{Status: status, NumEpisodesWatched: watched}}
+	}
+	list := &UserList{MediaType: "anime", Entries: []ListEntry{
+		entry(1, "Re:Zero, Part 2; Again", "2024-01-06", 12, "watching", 1),
+		entry(2, "No Start Date", "2024", 12, "watching", 4),
+		entry(3, "Unknown Length", "2024-01-06", 0, "watching", 0),
+		entry(4, "All Watched", "2024", 12, "watching", 12),
+		entry(5, "Dropped", "2024-01-06", 12, "dropped", 0),
+	}}
+
+	var out strings.Builder
+	if err := ExportScheduleICS(&out, list, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// Collect the events by UID, unfolding long lines first
+	events := make(map[string]map[string]string)
+	var event map[string]string
+	for _, line := range strings.Split(strings.ReplaceAll(out.String(), "\r\n ", ""), "\r\n") {
+		name, value, _ := strings.Cut(line, ":")
+		switch {
+		case line == "BEGIN:VEVENT":
+			event = make(map[string]string)
+		case line == "END:VEVENT":
+			events[event["UID"]] = event
+			event = nil
+		case event != nil:
+			event[name] = value
+		}
+	}
+
+	want := map[string]map[string]string{
+		"anime-1@yato": {
+			"DTSTART": "20240105T163000Z",
+			"RRULE":   "FREQ=WEEKLY;COUNT=12",
+			"SUMMARY": `Re:Zero\, Part 2\; Again`,
+		},
+		// Starts at the next slot, with only the unwatched episodes left
+		"anime-2@yato": {
+			"DTSTART": "20240126T163000Z",
+			"RRULE":   "FREQ=WEEKLY;COUNT=8",
+			"SUMMARY": "No Start Date",
+		},
+	}
+	if len(events) != len(want) {
+		t.Errorf("exported %d events, want %d:\n%s", len(events), len(want), out.String())
+	}
+	for uid, fields := range want {
+		event, ok := events[uid]
+		if !ok {
+			t.Errorf("no event %s", uid)
+			continue
+		}
+		for name, value := range fields {
+			if event[name] != value {
+				t.Errorf("%s %s = %q, want %q", uid, name, event[name], value)
+			}
+		}
+	}
+}
@@ -1,98 +1,587 @@
 package lib
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"image"
-	"image/jpeg"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 	"yato/config"
+
+	_ "golang.org/x/image/webp"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	cacheIndexFile = "index.json"
+
+	// prefetchWorkers bounds the number of covers downloaded in the background at once
+	prefetchWorkers = 4
+
+	// indexFlushDelay is how long access times are batched before the index
+	// is written back to disk
+	indexFlushDelay = 5 * time.Second
 )
 
+// imageExtensions maps the sniffed content types we can decode to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
 // ImageCache handles caching and retrieving images
 type ImageCache struct {
 	cacheDir string
+	maxSize  int64
+	maxAge   time.Duration
+
+	mu    sync.Mutex
+	index map[string]*cacheEntry
+
+	// dirty is set when the index has changes not yet on disk, which
+	// flushTimer writes back unless an eviction or Flush gets there first
+	dirty      bool
+	flushTimer *time.Timer
+
+	downloads singleflight.Group
+	workers   chan struct{}
+}
+
+// ImageRequest identifies an image to prefetch into the cache
+type ImageRequest struct {
+	MediaType string
+	MALID     int
+	Size      string
+	URL       string
+}
+
+// cacheEntry records a cached file, keyed by its path relative to the cache dir
+type cacheEntry struct {
+	Size     int64     `json:"size"`
+	Accessed time.Time `json:"accessed"`
+}
+
+// CacheStats summarizes the contents of the image cache
+type CacheStats struct {
+	Dir     string
+	Entries int
+	Size    int64
+	MaxSize int64
+	MaxAge  time.Duration
 }
 
 // NewImageCache creates a new ImageCache
-func NewImageCache() *ImageCache {
-	cacheDir := filepath.Join(config.ConfigDir, config.AppName, "cache")
-	return &ImageCache{cacheDir: cacheDir}
+func NewImageCache() (*ImageCache, error) {
+	baseDir, err := config.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheDir := filepath.Join(baseDir, "images")
+	if err := migrateLegacyCache(cacheDir); err != nil {
+		return nil, err
+	}
+
+	cacheConfig := config.GetConfig().Cache
+
+	c := &ImageCache{
+		cacheDir: cacheDir,
+		maxSize:  int64(cacheConfig.GetMaxSizeMB()) << 20,
+		maxAge:   time.Duration(cacheConfig.GetMaxAgeDays()) * 24 * time.Hour,
+		workers:  make(chan struct{}, prefetchWorkers),
+	}
+	c.loadIndex()
+
+	return c, nil
+}
+
+// migrateLegacyCache moves images cached by older versions, which lived
+// inside the config directory, to cacheDir
+func migrateLegacyCache(cacheDir string) error {
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return err
+	}
+
+	legacyDir := filepath.Join(configDir, "cache")
+	if _, err := os.Stat(legacyDir); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(cacheDir); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cacheDir), 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	// Rename fails across filesystems, in which case the cache is copied instead
+	if err := os.Rename(legacyDir, cacheDir); err == nil {
+		return nil
+	}
+
+	err = filepath.WalkDir(legacyDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(legacyDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(cacheDir, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate cache: %w", err)
+	}
+
+	return os.RemoveAll(legacyDir)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
 }
 
 // GetImage retrieves an image, either from cache or by downloading it
 func (c *ImageCache) GetImage(mediaType string, malID int, size string, url string) (image.Image, error) {
-	cachePath := c.getCachePath(mediaType, malID, size)
-
 	// Check if the image is already cached
-	if img, err := c.loadFromCache(cachePath); err == nil {
+	if img, err := c.CachedImage(mediaType, malID, size); err == nil {
 		return img, nil
 	}
 
-	// If not cached, download and cache the image
-	return c.downloadAndCache(url, cachePath)
+	basePath := c.getCachePath(mediaType, malID, size)
+
+	// If not cached, download and cache the image. Concurrent requests for
+	// the same image share a single download.
+	result, err, _ := c.downloads.Do(basePath, func() (interface{}, error) {
+		img, cachePath, err := c.downloadAndCache(url, basePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if info, err := os.Stat(cachePath); err == nil {
+			c.touch(cachePath, info.Size())
+		}
+		c.evict()
+
+		return img, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(image.Image), nil
+}
+
+// CachedImage returns an image only if it is already on disk, so callers
+// drawing the screen never wait on a download
+func (c *ImageCache) CachedImage(mediaType string, malID int, size string) (image.Image, error) {
+	img, cachePath, err := c.loadFromCache(c.getCachePath(mediaType, malID, size))
+	if err != nil {
+		return nil, err
+	}
+
+	c.touch(cachePath, -1)
+	return img, nil
+}
+
+// IsCached reports whether an image is on disk, without decoding it
+func (c *ImageCache) IsCached(mediaType string, malID int, size string) bool {
+	matches, _ := filepath.Glob(c.getCachePath(mediaType, malID, size) + ".*")
+	return len(matches) > 0
+}
+
+// Prefetch downloads the requested images in the background, in order and
+// with at most prefetchWorkers downloads in flight. Images already on disk are
+// skipped, and requests still queued when ctx is cancelled are dropped.
+func (c *ImageCache) Prefetch(ctx context.Context, requests ...ImageRequest) {
+	go func() {
+		for _, req := range requests {
+			select {
+			case c.workers <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(req ImageRequest) {
+				defer func() { <-c.workers }()
+				if ctx.Err() != nil || req.URL == "" {
+					return
+				}
+
+				if c.IsCached(req.MediaType, req.MALID, req.Size) {
+					return
+				}
+				c.GetImage(req.MediaType, req.MALID, req.Size, req.URL)
+			}(req)
+		}
+	}()
+}
+
+// Stats returns the number of cached files and their total size
+func (c *ImageCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		Dir:     c.cacheDir,
+		Entries: len(c.index),
+		MaxSize: c.maxSize,
+		MaxAge:  c.maxAge,
+	}
+	for _, entry := range c.index {
+		stats.Size += entry.Size
+	}
+
+	return stats
+}
+
+// Prune removes entries that haven't been accessed within the maximum age and
+// then evicts the least recently used entries until the cache fits its size
+// limit. Cached API responses older than the maximum age are removed too.
+func (c *ImageCache) Prune() (int, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed, freed := 0, int64(0)
+	cutoff := time.Now().Add(-c.maxAge)
+	for path, entry := range c.index {
+		if c.maxAge > 0 && entry.Accessed.Before(cutoff) {
+			if err := c.remove(path); err != nil {
+				return removed, freed, err
+			}
+			removed++
+			freed += entry.Size
+		}
+	}
+
+	n, size, err := c.evictLocked()
+	if err == nil && n == 0 && removed > 0 {
+		err = c.saveIndex()
+	}
+	removed, freed = removed+n, freed+size
+	if err != nil {
+		return removed, freed, err
+	}
+
+	n, size, err = pruneResponses(c.maxAge)
+	return removed + n, freed + size, err
 }
 
+// Clear removes every cached image and API response
+func (c *ImageCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.RemoveAll(c.cacheDir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	c.index = make(map[string]*cacheEntry)
+	c.dirty = false
+
+	return clearResponses()
+}
+
+// getCachePath returns the path of a cached image without its extension,
+// which depends on the format the server sent
 func (c *ImageCache) getCachePath(mediaType string, malID int, size string) string {
-	return filepath.Join(c.cacheDir, mediaType, fmt.Sprintf("%d", malID), size+".jpg")
+	return filepath.Join(c.cacheDir, mediaType, fmt.Sprintf("%d", malID), size)
 }
 
-func (c *ImageCache) loadFromCache(path string) (image.Image, error) {
+func (c *ImageCache) loadFromCache(basePath string) (image.Image, string, error) {
+	matches, err := filepath.Glob(basePath + ".*")
+	if err != nil {
+		return nil, "", err
+	}
+	if len(matches) == 0 {
+		return nil, "", os.ErrNotExist
+	}
+
+	path := matches[0]
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer file.Close()
 
-	img, err := jpeg.Decode(file)
+	img, _, err := image.Decode(file)
 	if err != nil {
-		return nil, err
+		// A file we can't decode would fail forever, so drop it and let
+		// the caller download it again
+		c.forget(path)
+		return nil, "", err
 	}
 
-	return img, nil
+	return img, path, nil
 }
 
-func (c *ImageCache) downloadAndCache(url, cachePath string) (image.Image, error) {
-	resp, err := http.Get(url)
+func (c *ImageCache) downloadAndCache(url, basePath string) (image.Image, string, error) {
+	resp, err := httpClient.Get(url)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to download image: %s", resp.Status)
+		return nil, "", fmt.Errorf("failed to download image: %s", resp.Status)
 	}
 
+	// Sniff the content type rather than trusting the URL, since MAL serves
+	// the same paths as JPEG, PNG or WebP
+	body := bufio.NewReader(resp.Body)
+	head, _ := body.Peek(512)
+	ext, ok := imageExtensions[http.DetectContentType(head)]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported image type: %s", http.DetectContentType(head))
+	}
+	cachePath := basePath + ext
+
 	// Ensure the cache directory exists
 	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	// Create the cache file
-	cacheFile, err := os.Create(cachePath)
+	// Download into a temporary file first, so an interrupted download
+	// never leaves a truncated image at the final path
+	tmpFile, err := os.CreateTemp(filepath.Dir(cachePath), "."+filepath.Base(basePath)+"-*.tmp")
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	defer cacheFile.Close()
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
 
-	// Download and write to cache file
-	_, err = io.Copy(cacheFile, resp.Body)
+	// Download and write to the temporary file
+	_, err = io.Copy(tmpFile, body)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// Reset file pointer and decode the image
-	_, err = cacheFile.Seek(0, 0)
+	_, err = tmpFile.Seek(0, 0)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	img, err := jpeg.Decode(cacheFile)
+	img, _, err := image.Decode(tmpFile)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return img, nil
+	if err := tmpFile.Close(); err != nil {
+		return nil, "", err
+	}
+	if err := os.Rename(tmpFile.Name(), cachePath); err != nil {
+		return nil, "", err
+	}
+
+	return img, cachePath, nil
+}
+
+// forget removes a cached file that turned out to be unusable
+func (c *ImageCache) forget(cachePath string) {
+	rel, err := filepath.Rel(c.cacheDir, cachePath)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(rel)
+	c.saveIndex()
+}
+
+// touch marks a cached file as just accessed. A negative size keeps the
+// size already recorded in the index.
+func (c *ImageCache) touch(cachePath string, size int64) {
+	rel, err := filepath.Rel(c.cacheDir, cachePath)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.index[rel]
+	if !ok {
+		entry = &cacheEntry{}
+		c.index[rel] = entry
+		if size < 0 {
+			if info, err := os.Stat(cachePath); err == nil {
+				size = info.Size()
+			}
+		}
+	}
+	if size >= 0 {
+		entry.Size = size
+	}
+	entry.Accessed = time.Now()
+
+	c.markDirty()
+}
+
+// markDirty schedules writing the index, batching changes made in quick
+// succession. Callers must hold c.mu.
+func (c *ImageCache) markDirty() {
+	c.dirty = true
+	if c.flushTimer == nil {
+		c.flushTimer = time.AfterFunc(indexFlushDelay, func() { c.Flush() })
+	}
+}
+
+// Flush writes pending index changes to disk. It should be called before
+// exiting so recent accesses aren't lost.
+func (c *ImageCache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+	return c.saveIndex()
+}
+
+// evict removes the least recently used entries until the cache fits its size limit
+func (c *ImageCache) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictLocked()
+}
+
+// evictLocked does the work of evict. The index is written right away only
+// when entries were removed. Callers must hold c.mu.
+func (c *ImageCache) evictLocked() (int, int64, error) {
+	var total int64
+	paths := make([]string, 0, len(c.index))
+	for path, entry := range c.index {
+		total += entry.Size
+		paths = append(paths, path)
+	}
+
+	if c.maxSize <= 0 || total <= c.maxSize {
+		c.markDirty()
+		return 0, 0, nil
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return c.index[paths[i]].Accessed.Before(c.index[paths[j]].Accessed)
+	})
+
+	removed, freed := 0, int64(0)
+	for _, path := range paths {
+		if total <= c.maxSize {
+			break
+		}
+		size := c.index[path].Size
+		if err := c.remove(path); err != nil {
+			c.markDirty()
+			return removed, freed, err
+		}
+		total -= size
+		freed += size
+		removed++
+	}
+
+	return removed, freed, c.saveIndex()
+}
+
+// remove deletes a cached file and its index entry. Callers must hold c.mu.
+func (c *ImageCache) remove(path string) error {
+	if err := os.Remove(filepath.Join(c.cacheDir, path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cached file: %w", err)
+	}
+	delete(c.index, path)
+
+	return nil
+}
+
+// loadIndex reads the cache index, rebuilding it from the files on disk
+// when it is missing or unreadable
+func (c *ImageCache) loadIndex() {
+	c.index = make(map[string]*cacheEntry)
+
+	data, err := os.ReadFile(filepath.Join(c.cacheDir, cacheIndexFile))
+	if err == nil && json.Unmarshal(data, &c.index) == nil {
+		return
+	}
+
+	c.index = make(map[string]*cacheEntry)
+	filepath.WalkDir(c.cacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() == cacheIndexFile {
+			return nil
+		}
+		// Leftovers from interrupted downloads
+		if filepath.Ext(path) == ".tmp" {
+			os.Remove(path)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(c.cacheDir, path)
+		if err != nil {
+			return nil
+		}
+		c.index[rel] = &cacheEntry{Size: info.Size(), Accessed: info.ModTime()}
+		return nil
+	})
+}
+
+// saveIndex writes the cache index to disk, including any changes waiting
+// for the debounced flush. Callers must hold c.mu.
+func (c *ImageCache) saveIndex() error {
+	if c.flushTimer != nil {
+		c.flushTimer.Stop()
+		c.flushTimer = nil
+	}
+	c.dirty = false
+
+	if err := os.MkdirAll(c.cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	data, err := json.Marshal(c.index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache index: %w", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(c.cacheDir, cacheIndexFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+
+	return nil
 }
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bytes"
+	"container/list"
 	"encoding/base64"
 	"fmt"
 	"image"
@@ -9,24 +10,110 @@ import (
 	"image/jpeg"
 	"image/png"
 	"os"
+	"os/exec"
 	"strings"
+	"sync"
 
 	"golang.org/x/image/draw"
 )
 
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+
+	// maxRenderedBytes bounds the memory held by memoized renderings. A
+	// kitty or iTerm2 cover takes a few hundred KB, so about a hundred fit.
+	maxRenderedBytes = 32 << 20
+)
+
 type ImageRenderer struct {
-	method string
+	method      string
+	multiplexer string
+	warning     string
+	cellWidth   int
+	cellHeight  int
+
+	mu       sync.Mutex
+	rendered renderCache
+}
+
+// renderCache holds the most recently used renderings up to maxBytes in
+// total, evicting the least recently used first
+type renderCache struct {
+	maxBytes int
+	size     int
+	entries  map[renderKey]*list.Element
+	order    *list.List // of *renderedImage, most recently used first
+}
+
+type renderedImage struct {
+	key    renderKey
+	escape string
+}
+
+func newRenderCache(maxBytes int) renderCache {
+	return renderCache{maxBytes: maxBytes, entries: make(map[renderKey]*list.Element), order: list.New()}
+}
+
+func (c *renderCache) get(k renderKey) (string, bool) {
+	elem, ok := c.entries[k]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*renderedImage).escape, true
+}
+
+func (c *renderCache) put(k renderKey, escape string) {
+	if elem, ok := c.entries[k]; ok {
+		c.size -= len(elem.Value.(*renderedImage).escape)
+		c.order.Remove(elem)
+	}
+	c.entries[k] = c.order.PushFront(&renderedImage{key: k, escape: escape})
+	c.size += len(escape)
+
+	// Always keep the newest one, even when it is over the limit by itself
+	for c.size > c.maxBytes && c.order.Len() > 1 {
+		oldest := c.order.Remove(c.order.Back()).(*renderedImage)
+		delete(c.entries, oldest.key)
+		c.size -= len(oldest.escape)
+	}
+}
+
+// renderKey identifies an escape string produced for an image at a given size
+type renderKey struct {
+	image      string
+	method     string
+	columns    int
+	rows       int
+	cellWidth  int
+	cellHeight int
 }
 
 func NewImageRenderer() *ImageRenderer {
-	method := determineRenderMethod()
-	return &ImageRenderer{method: method}
+	cellWidth, cellHeight := terminalCellSize()
+	r := &ImageRenderer{
+		method:      determineRenderMethod(),
+		multiplexer: determineMultiplexer(),
+		cellWidth:   cellWidth,
+		cellHeight:  cellHeight,
+		rendered:    newRenderCache(maxRenderedBytes),
+	}
+
+	// Graphics sequences only reach the outer terminal through the
+	// multiplexer's DCS passthrough, so fall back to text cells without it
+	if r.multiplexer == "tmux" && r.method != "none" && !tmuxPassthroughEnabled() {
+		r.warning = "tmux allow-passthrough is off; run `tmux set -g allow-passthrough on` for inline images"
+		r.method = "blocks"
+	}
+
+	return r
 }
 
 func determineRenderMethod() string {
-	if os.Getenv("TERM") == "xterm-kitty" {
+	if os.Getenv("TERM") == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "" {
 		return "kitty"
-	} else if os.Getenv("TERM_PROGRAM") == "iTerm.app" {
+	} else if os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("LC_TERMINAL") == "iTerm2" {
 		return "iterm2"
 	} else if os.Getenv("TERM") == "xterm-256color" && os.Getenv("VTE_VERSION") != "" {
 		return "sixel"
@@ -34,22 +121,142 @@ func determineRenderMethod() string {
 	return "none"
 }
 
+// determineMultiplexer reports whether we are running inside tmux or GNU screen
+func determineMultiplexer() string {
+	if os.Getenv("TMUX") != "" {
+		return "tmux"
+	} else if os.Getenv("STY") != "" {
+		return "screen"
+	}
+	return ""
+}
+
+// tmuxPassthroughEnabled checks the allow-passthrough option introduced in tmux 3.3.
+// Older versions don't have the option and always pass DCS sequences through.
+func tmuxPassthroughEnabled() bool {
+	out, err := exec.Command("tmux", "show-options", "-gv", "allow-passthrough").Output()
+	if err != nil {
+		return true
+	}
+	return strings.TrimSpace(string(out)) != "off"
+}
+
+// Warning returns a message describing why inline images are degraded, if any
+func (r *ImageRenderer) Warning() string {
+	return r.warning
+}
+
+// renderSettings is what a rendering depends on besides the image and its
+// box, read together so a concurrent Resize can't mix old and new values
+type renderSettings struct {
+	method     string
+	cellWidth  int
+	cellHeight int
+}
+
+func (r *ImageRenderer) settings() renderSettings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return renderSettings{method: r.method, cellWidth: r.cellWidth, cellHeight: r.cellHeight}
+}
+
+// RenderCached returns the memoized rendering of the image identified by key,
+// only calling load and encoding the image when it hasn't been rendered at
+// this size with the current method before
+func (r *ImageRenderer) RenderCached(key string, width, height int, load func() (image.Image, error)) (string, error) {
+	settings := r.settings()
+	k := renderKey{
+		image:      key,
+		method:     settings.method,
+		columns:    width,
+		rows:       height,
+		cellWidth:  settings.cellWidth,
+		cellHeight: settings.cellHeight,
+	}
+
+	r.mu.Lock()
+	rendered, ok := r.rendered.get(k)
+	r.mu.Unlock()
+	if ok {
+		return rendered, nil
+	}
+
+	img, err := load()
+	if err != nil {
+		return "", err
+	}
+	rendered = r.render(settings, img, width, height)
+
+	r.mu.Lock()
+	r.rendered.put(k, rendered)
+	r.mu.Unlock()
+
+	return rendered, nil
+}
+
+// Resize re-reads the terminal cell size and drops memoized renderings
+// when it changed, e.g. after a font size change
+func (r *ImageRenderer) Resize() {
+	cellWidth, cellHeight := terminalCellSize()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cellWidth != r.cellWidth || cellHeight != r.cellHeight {
+		r.cellWidth, r.cellHeight = cellWidth, cellHeight
+		r.rendered = newRenderCache(maxRenderedBytes)
+	}
+}
+
+// RenderImage draws img into a box of width columns and height rows
 func (r *ImageRenderer) RenderImage(img image.Image, width, height int) string {
-	switch r.method {
+	return r.render(r.settings(), img, width, height)
+}
+
+func (r *ImageRenderer) render(s renderSettings, img image.Image, width, height int) string {
+	switch s.method {
 	case "kitty":
-		return r.renderKitty(img, width, height)
+		return r.passthrough(renderKitty(img, width, height, s.cellWidth, s.cellHeight))
 	case "iterm2":
-		return r.renderITerm2(img, width, height)
+		return r.passthrough(renderITerm2(img, width, height, s.cellWidth, s.cellHeight))
 	case "sixel":
-		return r.renderSixel(img, width, height)
+		return r.passthrough(renderSixel(img, width*s.cellWidth, height*s.cellHeight))
+	case "blocks":
+		return renderBlocks(img, width, height)
 	case "ascii":
-		return r.renderASCII(img, width, height)
+		return renderASCII(img, width, height)
 	default:
 		return ""
 	}
 }
 
-func (r *ImageRenderer) renderKitty(img image.Image, width, height int) string {
+// passthrough wraps graphics sequences in the DCS envelope of the surrounding
+// multiplexer so they reach the outer terminal untouched
+func (r *ImageRenderer) passthrough(seq string) string {
+	switch r.multiplexer {
+	case "tmux":
+		// tmux requires every ESC inside the envelope to be doubled
+		return "\033Ptmux;" + strings.ReplaceAll(seq, "\033", "\033\033") + "\033\\"
+	case "screen":
+		// screen limits the length of a single DCS string, so split it up
+		const chunkSize = 768
+		var result strings.Builder
+		for i := 0; i < len(seq); i += chunkSize {
+			end := i + chunkSize
+			if end > len(seq) {
+				end = len(seq)
+			}
+			result.WriteString("\033P")
+			result.WriteString(seq[i:end])
+			result.WriteString("\033\\")
+		}
+		return result.String()
+	default:
+		return seq
+	}
+}
+
+func renderKitty(img image.Image, columns, rows, cellWidth, cellHeight int) string {
+	width, height := columns*cellWidth, rows*cellHeight
 	resized := image.NewRGBA(image.Rect(0, 0, width, height))
 	draw.NearestNeighbor.Scale(resized, resized.Rect, img, img.Bounds(), draw.Over, nil)
 
@@ -72,7 +279,7 @@ func (r *ImageRenderer) renderKitty(img image.Image, width, height int) string {
 	var result strings.Builder
 	for i, chunk := range chunks {
 		if i == 0 {
-			result.WriteString(fmt.Sprintf("\033_Ga=T,f=100,s=%d,v=%d,m=1;", width, height))
+			result.WriteString(fmt.Sprintf("\033_Ga=T,f=100,s=%d,v=%d,c=%d,r=%d,m=1;", width, height, columns, rows))
 		} else {
 			result.WriteString("\033_Gm=1;")
 		}
@@ -86,15 +293,39 @@ func (r *ImageRenderer) renderKitty(img image.Image, width, height int) string {
 	return result.String()
 }
 
-func (r *ImageRenderer) renderITerm2(img image.Image, width, height int) string {
-	// Implement iTerm2 inline image protocol
+func renderITerm2(img image.Image, columns, rows, cellWidth, cellHeight int) string {
+	// Downscale first so we never send more pixels than the cells can show
+	resized := scaleToFit(img, columns*cellWidth, rows*cellHeight)
+
 	var buf bytes.Buffer
-	jpeg.Encode(&buf, img, nil)
+	if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85}); err != nil {
+		return ""
+	}
 	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
-	return fmt.Sprintf("\033]1337;File=inline=1;width=%dpx;height=%dpx:%s\a", width, height, encoded)
+	args := fmt.Sprintf("inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1", buf.Len(), columns, rows)
+
+	// Large payloads are split into parts, which both iTerm2 and WezTerm
+	// handle more reliably than a single huge escape sequence
+	const chunkSize = 1 << 16
+	if len(encoded) <= chunkSize {
+		return fmt.Sprintf("\033]1337;File=%s:%s\a", args, encoded)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("\033]1337;MultipartFile=%s\a", args))
+	for i := 0; i < len(encoded); i += chunkSize {
+		end := i + chunkSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		result.WriteString(fmt.Sprintf("\033]1337;FilePart=%s\a", encoded[i:end]))
+	}
+	result.WriteString("\033]1337;FileEnd\a")
+
+	return result.String()
 }
 
-func (r *ImageRenderer) renderSixel(img image.Image, width, height int) string {
+func renderSixel(img image.Image, width, height int) string {
 	resized := image.NewRGBA(image.Rect(0, 0, width, height))
 	draw.NearestNeighbor.Scale(resized, resized.Rect, img, img.Bounds(), draw.Over, nil)
 
@@ -136,7 +367,43 @@ func (r *ImageRenderer) renderSixel(img image.Image, width, height int) string {
 	return sb.String()
 }
 
-func (r *ImageRenderer) renderASCII(img image.Image, width, height int) string {
+// renderBlocks draws the image with upper half blocks, using the foreground
+// and background colors of each cell for two vertically stacked pixels
+func renderBlocks(img image.Image, width, rows int) string {
+	resized := image.NewRGBA(image.Rect(0, 0, width, rows*2))
+	draw.NearestNeighbor.Scale(resized, resized.Rect, img, img.Bounds(), draw.Over, nil)
+
+	var sb strings.Builder
+	for y := 0; y < rows*2; y += 2 {
+		for x := 0; x < width; x++ {
+			top := resized.RGBAAt(x, y)
+			bottom := resized.RGBAAt(x, y+1)
+			sb.WriteString(fmt.Sprintf("\033[38;2;%d;%d;%dm\033[48;2;%d;%d;%dm▀",
+				top.R, top.G, top.B, bottom.R, bottom.G, bottom.B))
+		}
+		sb.WriteString("\033[0m\n")
+	}
+	return sb.String()
+}
+
+// scaleToFit downscales img to fit within maxWidth x maxHeight pixels while
+// keeping its aspect ratio. Images that already fit are returned as is.
+func scaleToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxWidth && bounds.Dy() <= maxHeight {
+		return img
+	}
+
+	scale := min(float64(maxWidth)/float64(bounds.Dx()), float64(maxHeight)/float64(bounds.Dy()))
+	width := max(1, int(float64(bounds.Dx())*scale))
+	height := max(1, int(float64(bounds.Dy())*scale))
+
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Rect, img, bounds, draw.Over, nil)
+	return resized
+}
+
+func renderASCII(img image.Image, width, height int) string {
 	// Implement a simple ASCII art renderer
 	// This is a very basic implementation and can be improved
 	bounds := img.Bounds()
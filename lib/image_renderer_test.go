@@ -0,0 +1,179 @@
+package lib
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// coverBenchColumns and coverBenchRows match the covers on the home screen
+const (
+	coverBenchColumns = 10
+	coverBenchRows    = 7
+)
+
+// testCover returns a cover-sized image with some variation, so encoders
+// can't take shortcuts on a flat color
+func testCover() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 225, 320))
+	for y := 0; y < 320; y++ {
+		for x := 0; x < 225; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+	return img
+}
+
+func testRenderer(method string) *ImageRenderer {
+	return &ImageRenderer{
+		method:     method,
+		cellWidth:  defaultCellWidth,
+		cellHeight: defaultCellHeight,
+		rendered:   newRenderCache(maxRenderedBytes),
+	}
+}
+
+// BenchmarkRenderImage measures encoding a cover from scratch, which is
+// what every frame paid before renderings were memoized
+func BenchmarkRenderImage(b *testing.B) {
+	cover := testCover()
+	for _, method := range []string{"kitty", "iterm2", "blocks"} {
+		b.Run(method, func(b *testing.B) {
+			r := testRenderer(method)
+			for i := 0; i < b.N; i++ {
+				r.RenderImage(cover, coverBenchColumns, coverBenchRows)
+			}
+		})
+	}
+}
+
+// BenchmarkRenderCached draws a frame of covers that have all been rendered
+// before. The cost per frame stays small however many covers are shown.
+func BenchmarkRenderCached(b *testing.B) {
+	cover := testCover()
+	load := func() (image.Image, error) { return cover, nil }
+
+	for _, covers := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("covers=%d", covers), func(b *testing.B) {
+			r := testRenderer("kitty")
+			keys := make([]string, covers)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("anime/%d/small", i)
+				r.RenderCached(keys[i], coverBenchColumns, coverBenchRows, load)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, key := range keys {
+					if _, err := r.RenderCached(key, coverBenchColumns, coverBenchRows, load); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkResize measures checking the cell size on a window resize, which
+// keeps the memoized renderings when the cell size didn't change
+func BenchmarkResize(b *testing.B) {
+	cover := testCover()
+	load := func() (image.Image, error) { return cover, nil }
+	r := testRenderer("kitty")
+	r.cellWidth, r.cellHeight = terminalCellSize()
+	r.RenderCached("anime/1/small", coverBenchColumns, coverBenchRows, load)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Resize()
+		r.RenderCached("anime/1/small", coverBenchColumns, coverBenchRows, load)
+	}
+}
+
+// TestRenderCachedDoesNotReload checks a second render of the same cover at
+// the same size doesn't load or encode the image again
+func TestRenderCachedDoesNotReload(t *testing.T) {
+	r := testRenderer("blocks")
+	loads := 0
+	load := func() (image.Image, error) {
+		loads++
+		return testCover(), nil
+	}
+
+	first, _ := r.RenderCached("anime/1/small", 4, 2, load)
+	second, _ := r.RenderCached("anime/1/small", 4, 2, load)
+	if loads != 1 {
+		t.Errorf("loaded %d times, want 1", loads)
+	}
+	if first != second {
+		t.Error("memoized rendering differs from the first one")
+	}
+
+	r.RenderCached("anime/1/small", 5, 2, load)
+	if loads != 2 {
+		t.Errorf("loaded %d times after changing the size, want 2", loads)
+	}
+}
+
+// TestRenderCachedEvictsLeastRecentlyUsed checks memoized renderings stay
+// within their byte limit, dropping the ones used longest ago
+func TestRenderCachedEvictsLeastRecentlyUsed(t *testing.T) {
+	r := testRenderer("blocks")
+	load := func() (image.Image, error) { return testCover(), nil }
+
+	first, _ := r.RenderCached("anime/1/small", 4, 2, load)
+	r.rendered.maxBytes = 2 * len(first)
+
+	r.RenderCached("anime/2/small", 4, 2, load)
+	// Use the first one again, so the second is now the oldest
+	r.RenderCached("anime/1/small", 4, 2, load)
+	r.RenderCached("anime/3/small", 4, 2, load)
+
+	if r.rendered.size > r.rendered.maxBytes {
+		t.Errorf("holding %d bytes, limit is %d", r.rendered.size, r.rendered.maxBytes)
+	}
+	for key, want := range map[string]bool{"anime/1/small": true, "anime/2/small": false, "anime/3/small": true} {
+		k := renderKey{image: key, method: "blocks", columns: 4, rows: 2, cellWidth: defaultCellWidth, cellHeight: defaultCellHeight}
+		if _, ok := r.rendered.entries[k]; ok != want {
+			t.Errorf("%s memoized = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	long := strings.Repeat("\033_Gm=1;AAAA\033\\", 150)
+
+	tests := []struct {
+		name        string
+		multiplexer string
+		seq         string
+		want        string
+	}{
+		{"none", "", "\033_Ga=T;AAAA\033\\", "\033_Ga=T;AAAA\033\\"},
+		{"tmux doubles escapes", "tmux", "\033_Ga=T;AAAA\033\\", "\033Ptmux;\033\033_Ga=T;AAAA\033\033\\\033\\"},
+		{"screen short", "screen", "\033]1337;File=:AAAA\a", "\033P\033]1337;File=:AAAA\a\033\\"},
+		{"screen chunks", "screen", long, screenChunks(long[:768], long[768:1536], long[1536:])},
+		{"screen empty", "screen", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ImageRenderer{multiplexer: tt.multiplexer}
+			if got := r.passthrough(tt.seq); got != tt.want {
+				t.Errorf("passthrough(%q) =\n%q\nwant\n%q", tt.seq, got, tt.want)
+			}
+		})
+	}
+}
+
+// screenChunks wraps each chunk in its own DCS string, the way GNU screen
+// needs long sequences split
+func screenChunks(chunks ...string) string {
+	var result strings.Builder
+	for _, chunk := range chunks {
+		result.WriteString("\033P" + chunk + "\033\\")
+	}
+	return result.String()
+}
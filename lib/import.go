@@ -0,0 +1,628 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"yato/config"
+)
+
+// ImportEntry is a list entry read from an export file, normalized to MAL's
+// statuses and scores. MALID is 0 until it has been resolved.
+type ImportEntry struct {
+	MediaType  string
+	MALID      int
+	Title      string
+	ListStatus ListStatus
+}
+
+// ParseImport reads a MAL XML export, an AniList MediaListCollection export or
+// a Kitsu library export, detecting the format from the content
+func ParseImport(data []byte) ([]ImportEntry, string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		entries, err := parseMALXML(trimmed)
+		return entries, "mal-xml", err
+	}
+
+	var probe struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		return nil, "", fmt.Errorf("unrecognized import file: %w", err)
+	}
+
+	// Kitsu's JSON:API documents have a top level data array, AniList's
+	// GraphQL responses a data object
+	if bytes.HasPrefix(bytes.TrimSpace(probe.Data), []byte("[")) {
+		entries, err := parseKitsu(trimmed)
+		return entries, "kitsu", err
+	}
+
+	entries, err := parseAniList(trimmed)
+	return entries, "anilist", err
+}
+
+func parseMALXML(data []byte) ([]ImportEntry, error) {
+	var export malXMLList
+	if err := xml.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse MAL XML: %w", err)
+	}
+
+	var entries []ImportEntry
+	for _, anime := range export.Anime {
+		entries = append(entries, ImportEntry{
+			MediaType: "anime",
+			MALID:     anime.SeriesID,
+			Title:     anime.SeriesTitle.Value,
+			ListStatus: ListStatus{
+				Status:             fromMALXMLStatus(anime.Status, "anime"),
+				Score:              anime.Score,
+				NumEpisodesWatched: anime.WatchedEpisodes,
+				IsRewatching:       anime.Rewatching == 1,
+				NumTimesRewatched:  anime.TimesWatched,
+				StartDate:          fromMALXMLDate(anime.StartDate),
+				FinishDate:         fromMALXMLDate(anime.FinishDate),
+				Comments:           anime.Comments.Value,
+				Tags:               splitTags(anime.Tags.Value),
+			},
+		})
+	}
+	for _, manga := range export.Manga {
+		entries = append(entries, ImportEntry{
+			MediaType: "manga",
+			MALID:     manga.MangaID,
+			Title:     manga.MangaTitle.Value,
+			ListStatus: ListStatus{
+				Status:          fromMALXMLStatus(manga.Status, "manga"),
+				Score:           manga.Score,
+				NumChaptersRead: manga.ReadChapters,
+				NumVolumesRead:  manga.ReadVolumes,
+				IsRereading:     manga.Rereading == 1,
+				NumTimesReread:  manga.TimesRead,
+				StartDate:       fromMALXMLDate(manga.StartDate),
+				FinishDate:      fromMALXMLDate(manga.FinishDate),
+				Comments:        manga.Comments.Value,
+				Tags:            splitTags(manga.Tags.Value),
+			},
+		})
+	}
+
+	return entries, nil
+}
+
+type aniListDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+func (d aniListDate) String() string {
+	switch {
+	case d.Year == 0:
+		return ""
+	case d.Month == 0:
+		return fmt.Sprintf("%04d", d.Year)
+	case d.Day == 0:
+		return fmt.Sprintf("%04d-%02d", d.Year, d.Month)
+	default:
+		return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+	}
+}
+
+type aniListCollection struct {
+	User struct {
+		MediaListOptions struct {
+			ScoreFormat string `json:"scoreFormat"`
+		} `json:"mediaListOptions"`
+	} `json:"user"`
+	Lists []struct {
+		Entries []struct {
+			Status          string      `json:"status"`
+			Score           float64     `json:"score"`
+			Progress        int         `json:"progress"`
+			ProgressVolumes int         `json:"progressVolumes"`
+			Repeat          int         `json:"repeat"`
+			Notes           string      `json:"notes"`
+			StartedAt       aniListDate `json:"startedAt"`
+			CompletedAt     aniListDate `json:"completedAt"`
+			Media           struct {
+				IDMal int    `json:"idMal"`
+				Type  string `json:"type"`
+				Title struct {
+					Romaji  string `json:"romaji"`
+					English string `json:"english"`
+				} `json:"title"`
+			} `json:"media"`
+		} `json:"entries"`
+	} `json:"lists"`
+}
+
+func parseAniList(data []byte) ([]ImportEntry, error) {
+	// Accept both the raw GraphQL response and just the collection
+	var response struct {
+		Data struct {
+			MediaListCollection *aniListCollection `json:"MediaListCollection"`
+		} `json:"data"`
+		MediaListCollection *aniListCollection `json:"MediaListCollection"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse AniList export: %w", err)
+	}
+
+	collection := response.Data.MediaListCollection
+	if collection == nil {
+		collection = response.MediaListCollection
+	}
+	if collection == nil {
+		return nil, fmt.Errorf("failed to parse AniList export: no MediaListCollection found")
+	}
+
+	var entries []ImportEntry
+	for _, list := range collection.Lists {
+		for _, e := range list.Entries {
+			mediaType := strings.ToLower(e.Media.Type)
+			title := e.Media.Title.Romaji
+			if title == "" {
+				title = e.Media.Title.English
+			}
+
+			status := ListStatus{
+				Status:     fromAniListStatus(e.Status, mediaType),
+				Score:      fromAniListScore(e.Score, collection.User.MediaListOptions.ScoreFormat),
+				StartDate:  e.StartedAt.String(),
+				FinishDate: e.CompletedAt.String(),
+				Comments:   e.Notes,
+			}
+			if mediaType == "manga" {
+				status.NumChaptersRead = e.Progress
+				status.NumVolumesRead = e.ProgressVolumes
+				status.NumTimesReread = e.Repeat
+				status.IsRereading = e.Status == "REPEATING"
+			} else {
+				status.NumEpisodesWatched = e.Progress
+				status.NumTimesRewatched = e.Repeat
+				status.IsRewatching = e.Status == "REPEATING"
+			}
+
+			entries = append(entries, ImportEntry{
+				MediaType:  mediaType,
+				MALID:      e.Media.IDMal,
+				Title:      title,
+				ListStatus: status,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+type kitsuResource struct {
+	Type          string `json:"type"`
+	ID            string `json:"id"`
+	Attributes    json.RawMessage
+	Relationships map[string]struct {
+		Data *struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"data"`
+	} `json:"relationships"`
+}
+
+func parseKitsu(data []byte) ([]ImportEntry, error) {
+	var document struct {
+		Data     []kitsuResource `json:"data"`
+		Included []kitsuResource `json:"included"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse Kitsu export: %w", err)
+	}
+
+	// Titles and MAL mappings of the included media, keyed by "anime/1"
+	titles := map[string]string{}
+	malIDs := map[string]int{}
+	for _, resource := range document.Included {
+		switch resource.Type {
+		case "anime", "manga":
+			var attributes struct {
+				CanonicalTitle string `json:"canonicalTitle"`
+			}
+			json.Unmarshal(resource.Attributes, &attributes)
+			titles[resource.Type+"/"+resource.ID] = attributes.CanonicalTitle
+		case "mappings":
+			var attributes struct {
+				ExternalSite string `json:"externalSite"`
+				ExternalID   string `json:"externalId"`
+			}
+			json.Unmarshal(resource.Attributes, &attributes)
+			item := resource.Relationships["item"].Data
+			if item == nil || attributes.ExternalSite != "myanimelist/"+item.Type {
+				continue
+			}
+			if id, err := strconv.Atoi(attributes.ExternalID); err == nil {
+				malIDs[item.Type+"/"+item.ID] = id
+			}
+		}
+	}
+
+	var entries []ImportEntry
+	for _, resource := range document.Data {
+		if resource.Type != "libraryEntries" {
+			continue
+		}
+
+		var attributes struct {
+			Status         string `json:"status"`
+			Progress       int    `json:"progress"`
+			VolumesOwned   int    `json:"volumesOwned"`
+			RatingTwenty   int    `json:"ratingTwenty"`
+			ReconsumeCount int    `json:"reconsumeCount"`
+			Reconsuming    bool   `json:"reconsuming"`
+			Notes          string `json:"notes"`
+			StartedAt      string `json:"startedAt"`
+			FinishedAt     string `json:"finishedAt"`
+		}
+		if err := json.Unmarshal(resource.Attributes, &attributes); err != nil {
+			return nil, fmt.Errorf("failed to parse Kitsu library entry: %w", err)
+		}
+
+		mediaType, mediaID := "", ""
+		for _, name := range []string{"anime", "manga"} {
+			if rel := resource.Relationships[name].Data; rel != nil {
+				mediaType, mediaID = name, rel.ID
+			}
+		}
+		if mediaType == "" {
+			continue
+		}
+
+		key := mediaType + "/" + mediaID
+		status := ListStatus{
+			Status:     fromKitsuStatus(attributes.Status, mediaType),
+			Score:      (attributes.RatingTwenty + 1) / 2,
+			StartDate:  dateOnly(attributes.StartedAt),
+			FinishDate: dateOnly(attributes.FinishedAt),
+			Comments:   attributes.Notes,
+		}
+		if mediaType == "manga" {
+			status.NumChaptersRead = attributes.Progress
+			status.NumTimesReread = attributes.ReconsumeCount
+			status.IsRereading = attributes.Reconsuming
+		} else {
+			status.NumEpisodesWatched = attributes.Progress
+			status.NumTimesRewatched = attributes.ReconsumeCount
+			status.IsRewatching = attributes.Reconsuming
+		}
+
+		entries = append(entries, ImportEntry{
+			MediaType:  mediaType,
+			MALID:      malIDs[key],
+			Title:      titles[key],
+			ListStatus: status,
+		})
+	}
+
+	return entries, nil
+}
+
+// resolveCandidates is how many search results are checked for a title
+// matching an imported entry
+const resolveCandidates = 5
+
+// MALMatch is the MAL title an imported entry without a MAL ID was matched to
+type MALMatch struct {
+	ID    int
+	Title string
+
+	// Exact is set when the titles only differ in case and punctuation.
+	// Other matches are the search's best guess and may be wrong.
+	Exact bool
+}
+
+// ResolveMALID looks up an entry without a MAL ID by searching for its title.
+// A result with the same title is preferred over the top result.
+func ResolveMALID(entry ImportEntry) (MALMatch, error) {
+	if entry.Title == "" {
+		return MALMatch{}, fmt.Errorf("no MAL ID or title to search for")
+	}
+
+	titles, err := SearchTitles(entry.MediaType, entry.Title, resolveCandidates)
+	if err != nil {
+		return MALMatch{}, err
+	}
+	if len(titles) == 0 {
+		return MALMatch{}, fmt.Errorf("no match for %q", entry.Title)
+	}
+
+	want := normalizeTitle(entry.Title)
+	for _, title := range titles {
+		if normalizeTitle(title.Title) == want {
+			return MALMatch{ID: title.ID, Title: title.Title, Exact: true}, nil
+		}
+	}
+
+	return MALMatch{ID: titles[0].ID, Title: titles[0].Title}, nil
+}
+
+// normalizeTitle keeps only the lowercased letters and digits of a title, so
+// "Steins;Gate" and "Steins Gate" compare equal
+func normalizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, title)
+}
+
+// PlanImport compares an imported entry with the user's list and returns the
+// update needed to apply it, or nil if MAL already has everything. Empty
+// imported fields never overwrite what is on MAL.
+func PlanImport(entry ImportEntry, list *UserList) *ListUpdate {
+	current := ListStatus{}
+	updatedAt := ""
+	if existing := list.Find(entry.MALID); existing != nil {
+		current = existing.ListStatus
+		updatedAt = current.UpdatedAt
+	}
+
+	fields := map[string]string{}
+	setString := func(field, imported, existing string) {
+		if imported != "" && imported != existing {
+			fields[field] = imported
+		}
+	}
+	setInt := func(field string, imported, existing int) {
+		if imported != 0 && imported != existing {
+			fields[field] = strconv.Itoa(imported)
+		}
+	}
+
+	target := entry.ListStatus
+	setString("status", target.Status, current.Status)
+	setInt("score", target.Score, current.Score)
+	setString("start_date", target.StartDate, current.StartDate)
+	setString("finish_date", target.FinishDate, current.FinishDate)
+	setString("comments", target.Comments, current.Comments)
+	setString("tags", strings.Join(target.Tags, ","), strings.Join(current.Tags, ","))
+	if entry.MediaType == "manga" {
+		setInt("num_chapters_read", target.NumChaptersRead, current.NumChaptersRead)
+		setInt("num_volumes_read", target.NumVolumesRead, current.NumVolumesRead)
+		setInt("num_times_reread", target.NumTimesReread, current.NumTimesReread)
+		if target.IsRereading && !current.IsRereading {
+			fields["is_rereading"] = "true"
+		}
+	} else {
+		setInt("num_watched_episodes", target.NumEpisodesWatched, current.NumEpisodesWatched)
+		setInt("num_times_rewatched", target.NumTimesRewatched, current.NumTimesRewatched)
+		if target.IsRewatching && !current.IsRewatching {
+			fields["is_rewatching"] = "true"
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &ListUpdate{
+		MediaType:     entry.MediaType,
+		MALID:         entry.MALID,
+		Title:         entry.Title,
+		Fields:        fields,
+		BaseUpdatedAt: updatedAt,
+	}
+}
+
+// ImportProgress records which entries of an import file were already applied,
+// so an interrupted import can be resumed
+type ImportProgress struct {
+	path string
+
+	File    string          `json:"file"`
+	Started time.Time       `json:"started"`
+	Done    map[string]bool `json:"done"`
+}
+
+// LoadImportProgress returns the progress of importing the file with the
+// given contents, starting fresh if it was never imported before
+func LoadImportProgress(file string, data []byte) (*ImportProgress, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	progress := &ImportProgress{
+		path:    filepath.Join(dataDir, "imports", hex.EncodeToString(sum[:8])+".json"),
+		File:    file,
+		Started: time.Now(),
+		Done:    map[string]bool{},
+	}
+
+	saved, err := os.ReadFile(progress.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return progress, nil
+		}
+		return nil, fmt.Errorf("failed to read import progress: %w", err)
+	}
+
+	if err := json.Unmarshal(saved, progress); err != nil {
+		return nil, fmt.Errorf("failed to decode import progress: %w", err)
+	}
+	if progress.Done == nil {
+		progress.Done = map[string]bool{}
+	}
+
+	return progress, nil
+}
+
+// IsDone reports whether the entry was already applied. Entries without a
+// MAL ID are recognized by their title, so they aren't searched for again.
+func (p *ImportProgress) IsDone(entry ImportEntry) bool {
+	for _, key := range progressKeys(entry) {
+		if p.Done[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkDone records the entry as applied and saves the progress file
+func (p *ImportProgress) MarkDone(entry ImportEntry) error {
+	for _, key := range progressKeys(entry) {
+		p.Done[key] = true
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode import progress: %w", err)
+	}
+
+	return writeFileAtomic(p.path, data, 0600)
+}
+
+// progressKeys returns the keys an entry is recorded under in the progress
+// file: its MAL ID once known and its title
+func progressKeys(entry ImportEntry) []string {
+	var keys []string
+	if entry.MALID != 0 {
+		keys = append(keys, entry.MediaType+"/"+strconv.Itoa(entry.MALID))
+	}
+	if entry.Title != "" {
+		keys = append(keys, entry.MediaType+"/title:"+entry.Title)
+	}
+	return keys
+}
+
+// Finish removes the progress file once the import is complete
+func (p *ImportProgress) Finish() error {
+	if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// fromMALXMLStatus converts the status names of MAL's XML, or the numeric
+// codes found in older exports, to API statuses
+func fromMALXMLStatus(status, mediaType string) string {
+	switch strings.ToLower(status) {
+	case "watching", "reading", "1":
+		return progressStatus(mediaType)
+	case "completed", "2":
+		return "completed"
+	case "on-hold", "3":
+		return "on_hold"
+	case "dropped", "4":
+		return "dropped"
+	case "plan to watch", "plan to read", "6":
+		return planStatus(mediaType)
+	}
+	return ""
+}
+
+// fromAniListScore converts a score in the user's AniList score format to
+// MAL's 10 point scale
+func fromAniListScore(score float64, format string) int {
+	switch format {
+	case "POINT_100":
+		score /= 10
+	case "POINT_10", "POINT_10_DECIMAL":
+	case "POINT_5":
+		score *= 2
+	case "POINT_3":
+		// Smileys, from 1 for a frown to 3 for a smile
+		score *= 3
+	default:
+		// Exports without the user's options only leave a guess: anything
+		// above 10 must be on the 100 point scale
+		if score > 10 {
+			score /= 10
+		}
+	}
+	return min(10, int(math.Round(score)))
+}
+
+func fromAniListStatus(status, mediaType string) string {
+	switch status {
+	case "CURRENT", "REPEATING":
+		return progressStatus(mediaType)
+	case "COMPLETED":
+		return "completed"
+	case "PAUSED":
+		return "on_hold"
+	case "DROPPED":
+		return "dropped"
+	case "PLANNING":
+		return planStatus(mediaType)
+	}
+	return ""
+}
+
+func fromKitsuStatus(status, mediaType string) string {
+	switch status {
+	case "current":
+		return progressStatus(mediaType)
+	case "completed":
+		return "completed"
+	case "on_hold":
+		return "on_hold"
+	case "dropped":
+		return "dropped"
+	case "planned":
+		return planStatus(mediaType)
+	}
+	return ""
+}
+
+func progressStatus(mediaType string) string {
+	if mediaType == "manga" {
+		return "reading"
+	}
+	return "watching"
+}
+
+func planStatus(mediaType string) string {
+	if mediaType == "manga" {
+		return "plan_to_read"
+	}
+	return "plan_to_watch"
+}
+
+// fromMALXMLDate converts MAL's XML dates, where unknown parts are zero, to
+// the API format, which leaves them out
+func fromMALXMLDate(date string) string {
+	parts := strings.Split(date, "-")
+	for len(parts) > 0 && strings.Trim(parts[len(parts)-1], "0") == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, "-")
+}
+
+// dateOnly cuts a timestamp like 2024-01-02T10:00:00.000Z down to its date
+func dateOnly(timestamp string) string {
+	if len(timestamp) > 10 {
+		return timestamp[:10]
+	}
+	return timestamp
+}
+
+func splitTags(tags string) []string {
+	var result []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
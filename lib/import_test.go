@@ -0,0 +1,220 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+const malXMLExport = `<?xml version="1.0" encoding="UTF-8" ?>
+<myanimelist>
+	<myinfo><user_export_type>1</user_export_type></myinfo>
+	<anime>
+		<series_animedb_id>5114</series_animedb_id>
+		<series_title><![CDATA[Fullmetal Alchemist: Brotherhood]]></series_title>
+		<my_watched_episodes>64</my_watched_episodes>
+		<my_start_date>2020-01-02</my_start_date>
+		<my_finish_date>0000-00-00</my_finish_date>
+		<my_score>10</my_score>
+		<my_status>Completed</my_status>
+		<my_times_watched>1</my_times_watched>
+		<my_tags><![CDATA[classic, shounen]]></my_tags>
+	</anime>
+	<manga>
+		<manga_mangadb_id>2</manga_mangadb_id>
+		<manga_title><![CDATA[Berserk]]></manga_title>
+		<my_read_volumes>3</my_read_volumes>
+		<my_read_chapters>20</my_read_chapters>
+		<my_score>0</my_score>
+		<my_status>1</my_status>
+	</manga>
+</myanimelist>`
+
+const aniListExport = `{"data": {"MediaListCollection": {
+	"user": {"mediaListOptions": {"scoreFormat": "POINT_100"}},
+	"lists": [{"entries": [
+		{"status": "REPEATING", "score": 85, "progress": 3, "repeat": 1,
+		 "startedAt": {"year": 2021, "month": 4, "day": 0},
+		 "media": {"idMal": 5114, "type": "ANIME", "title": {"romaji": "Hagane no Renkinjutsushi"}}},
+		{"status": "PLANNING", "score": 0, "progressVolumes": 2,
+		 "media": {"idMal": 0, "type": "MANGA", "title": {"english": "Berserk"}}}
+	]}]
+}}}`
+
+const kitsuExport = `{
+	"data": [
+		{"type": "libraryEntries", "id": "1",
+		 "attributes": {"status": "current", "progress": 7, "ratingTwenty": 17, "startedAt": "2022-03-04T10:00:00.000Z"},
+		 "relationships": {"anime": {"data": {"type": "anime", "id": "42"}}}},
+		{"type": "libraryEntries", "id": "2",
+		 "attributes": {"status": "planned"},
+		 "relationships": {"manga": {"data": {"type": "manga", "id": "7"}}}}
+	],
+	"included": [
+		{"type": "anime", "id": "42", "attributes": {"canonicalTitle": "Cowboy Bebop"}},
+		{"type": "mappings", "id": "9",
+		 "attributes": {"externalSite": "myanimelist/anime", "externalId": "1"},
+		 "relationships": {"item": {"data": {"type": "anime", "id": "42"}}}},
+		{"type": "manga", "id": "7", "attributes": {"canonicalTitle": "Monster"}}
+	]
+}`
+
+func TestParseImport(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		want   []ImportEntry
+	}{
+		{
+			name:   "mal xml",
+			data:   malXMLExport,
+			format: "mal-xml",
+			want: []ImportEntry{
+				{MediaType: "anime", MALID: 5114, Title: "Fullmetal Alchemist: Brotherhood", ListStatus: ListStatus{
+					Status: "completed", Score: 10, NumEpisodesWatched: 64, NumTimesRewatched: 1,
+					StartDate: "2020-01-02", Tags: []string{"classic", "shounen"},
+				}},
+				{MediaType: "manga", MALID: 2, Title: "Berserk", ListStatus: ListStatus{
+					Status: "reading", NumChaptersRead: 20, NumVolumesRead: 3,
+				}},
+			},
+		},
+		{
+			name:   "anilist",
+			data:   aniListExport,
+			format: "anilist",
+			want: []ImportEntry{
+				{MediaType: "anime", MALID: 5114, Title: "Hagane no Renkinjutsushi", ListStatus: ListStatus{
+					Status: "watching", Score: 9, NumEpisodesWatched: 3, NumTimesRewatched: 1,
+					IsRewatching: true, StartDate: "2021-04",
+				}},
+				{MediaType: "manga", Title: "Berserk", ListStatus: ListStatus{
+					Status: "plan_to_read", NumVolumesRead: 2,
+				}},
+			},
+		},
+		{
+			name:   "kitsu",
+			data:   kitsuExport,
+			format: "kitsu",
+			want: []ImportEntry{
+				{MediaType: "anime", MALID: 1, Title: "Cowboy Bebop", ListStatus: ListStatus{
+					Status: "watching", Score: 9, NumEpisodesWatched: 7, StartDate: "2022-03-04",
+				}},
+				{MediaType: "manga", Title: "Monster", ListStatus: ListStatus{
+					Status: "plan_to_read",
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, format, err := ParseImport([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.format {
+				t.Errorf("format = %q, want %q", format, tt.format)
+			}
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("entries:\n%+v\nwant:\n%+v", entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportRejectsUnknownFiles(t *testing.T) {
+	for _, data := range []string{"name,score\nBerserk,10", `{"data": {}}`} {
+		if _, _, err := ParseImport([]byte(data)); err == nil {
+			t.Errorf("ParseImport(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestFromAniListScore(t *testing.T) {
+	tests := []struct {
+		score  float64
+		format string
+		want   int
+	}{
+		{85, "POINT_100", 9},
+		{100, "POINT_100", 10},
+		{7.5, "POINT_10_DECIMAL", 8},
+		{7, "POINT_10", 7},
+		{4, "POINT_5", 8},
+		{5, "POINT_5", 10},
+		{1, "POINT_3", 3},
+		{3, "POINT_3", 9},
+		{0, "POINT_100", 0},
+		// Without the format, scores above 10 are taken as out of 100
+		{72, "", 7},
+		{6, "", 6},
+	}
+
+	for _, tt := range tests {
+		if got := fromAniListScore(tt.score, tt.format); got != tt.want {
+			t.Errorf("fromAniListScore(%v, %q) = %d, want %d", tt.score, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestResolveMALID(t *testing.T) {
+	mal := stubMAL(t)
+	entry := ImportEntry{MediaType: "anime", Title: "Steins Gate"}
+
+	mal.titles = []Title{{ID: 30484, Title: "Steins;Gate 0"}, {ID: 9253, Title: "Steins;Gate"}}
+	match, err := ResolveMALID(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (MALMatch{ID: 9253, Title: "Steins;Gate", Exact: true}); match != want {
+		t.Errorf("match = %+v, want %+v", match, want)
+	}
+
+	// The top result is only a guess when no title matches
+	match, err = ResolveMALID(ImportEntry{MediaType: "anime", Title: "Steins Gate Zero"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.ID != 30484 || match.Exact {
+		t.Errorf("match = %+v, want an inexact match on 30484", match)
+	}
+
+	mal.titles = nil
+	if _, err := ResolveMALID(ImportEntry{MediaType: "anime", Title: "Nothing"}); err == nil {
+		t.Error("resolved a title without search results")
+	}
+}
+
+func TestImportProgressRecognizesResolvedTitles(t *testing.T) {
+	t.Setenv("YATO_DATA_DIR", t.TempDir())
+	data := []byte(aniListExport)
+
+	progress, err := LoadImportProgress("export.json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := ImportEntry{MediaType: "manga", Title: "Berserk"}
+	if progress.IsDone(entry) {
+		t.Fatal("entry done before being applied")
+	}
+
+	resolved := entry
+	resolved.MALID = 2
+	if err := progress.MarkDone(resolved); err != nil {
+		t.Fatal(err)
+	}
+
+	// A resumed import knows the entry before searching for its ID again
+	resumed, err := LoadImportProgress("export.json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.IsDone(entry) || !resumed.IsDone(resolved) {
+		t.Error("applied entry not recognized after resuming")
+	}
+	if resumed.IsDone(ImportEntry{MediaType: "anime", Title: "Berserk"}) {
+		t.Error("entry of the other media type recognized as done")
+	}
+}
@@ -0,0 +1,300 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrUpdateQueued is returned by SubmitListUpdate when MAL couldn't be
+// reached and the update was saved to be replayed later
+var ErrUpdateQueued = errors.New("update queued until back online")
+
+// PendingUpdate is a list update waiting in the journal to be sent to MAL
+type PendingUpdate struct {
+	ID       string     `json:"id"`
+	Update   ListUpdate `json:"update"`
+	QueuedAt time.Time  `json:"queued_at"`
+
+	// Conflict is set when the entry changed on MAL after the update was
+	// made. Conflicting updates are only sent when forced.
+	Conflict bool   `json:"conflict,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SyncResult summarizes a replay of the journal
+type SyncResult struct {
+	Applied   int
+	Conflicts int
+	Remaining int
+}
+
+var (
+	journalMu sync.Mutex
+
+	// syncMu keeps journal replays from overlapping
+	syncMu sync.Mutex
+)
+
+// SubmitListUpdate sends the update to MAL. When MAL can't be reached, or
+// earlier updates to the same title are still pending, the update is appended
+// to the journal instead and ErrUpdateQueued is returned.
+func SubmitListUpdate(update ListUpdate) (*ListStatus, error) {
+	journalMu.Lock()
+	pending, err := loadJournal()
+	if err != nil {
+		journalMu.Unlock()
+		return nil, err
+	}
+	for _, p := range pending {
+		if p.Update.MediaType == update.MediaType && p.Update.MALID == update.MALID {
+			defer journalMu.Unlock()
+			return nil, queueUpdate(pending, update)
+		}
+	}
+	journalMu.Unlock()
+
+	status, err := UpdateListStatus(update)
+	if err != nil {
+		if isNetworkError(err) {
+			offline.Store(true)
+
+			journalMu.Lock()
+			defer journalMu.Unlock()
+			pending, err := loadJournal()
+			if err != nil {
+				return nil, err
+			}
+			return nil, queueUpdate(pending, update)
+		}
+		return nil, err
+	}
+
+	return status, UpdateLocalEntry(update.MediaType, update.MALID, *status)
+}
+
+// PendingUpdates returns the journal in the order the updates were made
+func PendingUpdates() ([]PendingUpdate, error) {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	return loadJournal()
+}
+
+// ApplyPending overlays the pending updates for a title onto its list status,
+// so the UI shows changes that haven't reached MAL yet
+func ApplyPending(pending []PendingUpdate, mediaType string, malID int, status ListStatus) ListStatus {
+	for _, p := range pending {
+		if p.Update.MediaType == mediaType && p.Update.MALID == malID {
+			status = p.Update.Apply(status)
+		}
+	}
+	return status
+}
+
+// DiscardPending drops an update from the journal without sending it
+func DiscardPending(id string) error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	pending, err := loadJournal()
+	if err != nil {
+		return err
+	}
+
+	kept := pending[:0]
+	for _, p := range pending {
+		if p.ID != id {
+			kept = append(kept, p)
+		}
+	}
+
+	return saveJournal(kept)
+}
+
+// SyncPending replays the journal against MAL in order. Updates to entries
+// that changed on MAL since they were made are marked as conflicts and kept,
+// unless their ID is in force. Syncing stops at the first network error.
+//
+// The journal is only locked to take a snapshot and to merge the outcome
+// back, so updates can be queued or discarded while MAL is being contacted.
+func SyncPending(force ...string) (SyncResult, error) {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+
+	var result SyncResult
+
+	journalMu.Lock()
+	pending, err := loadJournal()
+	journalMu.Unlock()
+	if err != nil || len(pending) == 0 {
+		return result, err
+	}
+
+	forced := make(map[string]bool, len(force))
+	for _, id := range force {
+		forced[id] = true
+	}
+
+	// Once an update to a title is held back, later ones must wait too
+	blocked := make(map[string]bool)
+	applied := make(map[string]bool)
+	// rebased holds the updated_at of titles changed by this run
+	rebased := make(map[string]string)
+
+	for i := range pending {
+		p := &pending[i]
+		key := pendingKey(p.Update)
+		if blocked[key] {
+			continue
+		}
+		if base, ok := rebased[key]; ok && p.Update.BaseUpdatedAt != "" {
+			// Later updates to the title were made on top of the one just
+			// applied, so they expect the entry as it is now
+			p.Update.BaseUpdatedAt = base
+		}
+
+		if !forced[p.ID] && p.Update.BaseUpdatedAt != "" {
+			current, err := GetListStatus(p.Update.MediaType, p.Update.MALID)
+			if err != nil {
+				if isNetworkError(err) {
+					offline.Store(true)
+					break
+				}
+				p.Error = err.Error()
+				blocked[key] = true
+				continue
+			}
+
+			if current != nil && current.UpdatedAt != p.Update.BaseUpdatedAt {
+				p.Conflict = true
+				result.Conflicts++
+				blocked[key] = true
+				continue
+			}
+		}
+
+		status, err := UpdateListStatus(p.Update)
+		if err != nil {
+			if isNetworkError(err) {
+				offline.Store(true)
+				break
+			}
+			p.Error = err.Error()
+			blocked[key] = true
+			continue
+		}
+
+		offline.Store(false)
+		result.Applied++
+		applied[p.ID] = true
+		rebased[key] = status.UpdatedAt
+		UpdateLocalEntry(p.Update.MediaType, p.Update.MALID, *status)
+	}
+
+	// Merge into the journal as it is now, which may have gained or lost
+	// updates while syncing
+	outcome := make(map[string]PendingUpdate, len(pending))
+	for _, p := range pending {
+		outcome[p.ID] = p
+	}
+
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	current, err := loadJournal()
+	if err != nil {
+		return result, err
+	}
+
+	remaining := make([]PendingUpdate, 0, len(current))
+	for _, p := range current {
+		if applied[p.ID] {
+			continue
+		}
+		if updated, ok := outcome[p.ID]; ok {
+			p = updated
+		}
+		if base, ok := rebased[pendingKey(p.Update)]; ok && p.Update.BaseUpdatedAt != "" {
+			p.Update.BaseUpdatedAt = base
+		}
+		remaining = append(remaining, p)
+	}
+
+	result.Remaining = len(remaining)
+	return result, saveJournal(remaining)
+}
+
+func pendingKey(update ListUpdate) string {
+	return update.MediaType + "/" + strconv.Itoa(update.MALID)
+}
+
+func queueUpdate(pending []PendingUpdate, update ListUpdate) error {
+	now := time.Now()
+	pending = append(pending, PendingUpdate{
+		ID:       strconv.FormatInt(now.UnixNano(), 36),
+		Update:   update,
+		QueuedAt: now,
+	})
+
+	if err := saveJournal(pending); err != nil {
+		return err
+	}
+
+	return ErrUpdateQueued
+}
+
+func journalPath() (string, error) {
+	userDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userDir, "pending.json"), nil
+}
+
+// loadJournal reads the journal. Callers must hold journalMu.
+func loadJournal() ([]PendingUpdate, error) {
+	path, err := journalPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pending updates: %w", err)
+	}
+
+	var pending []PendingUpdate
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, fmt.Errorf("failed to decode pending updates: %w", err)
+	}
+
+	return pending, nil
+}
+
+// saveJournal writes the journal. Callers must hold journalMu.
+func saveJournal(pending []PendingUpdate) error {
+	path, err := journalPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pending updates: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write pending updates: %w", err)
+	}
+
+	return nil
+}
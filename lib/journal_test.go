@@ -0,0 +1,336 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"yato/config"
+)
+
+// fakeMAL stands in for the list and search endpoints of the MAL API,
+// keeping list statuses in memory and bumping updated_at on every change
+type fakeMAL struct {
+	statuses map[string]ListStatus
+	// titles are the search results, whatever the query
+	titles  []Title
+	patches []string
+	clock   int
+	offline bool
+
+	// onPatch runs before a change is applied, e.g. to queue another update
+	// while a sync is in progress
+	onPatch func()
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// stubMAL routes API requests to a fakeMAL and keeps the journal and the
+// list mirror in temporary directories for the duration of the test
+func stubMAL(t *testing.T) *fakeMAL {
+	t.Setenv("YATO_DATA_DIR", t.TempDir())
+	cacheDir := t.TempDir()
+	t.Setenv("YATO_CACHE_DIR", cacheDir)
+
+	// The response cache dir is only looked up once per process
+	responseDir()
+	savedDir := responseCacheDir
+	t.Cleanup(func() { responseCacheDir = savedDir })
+	responseCacheDir = filepath.Join(cacheDir, "http")
+
+	saved := *config.GetConfig()
+	t.Cleanup(func() { *config.GetConfig() = saved })
+	config.GetConfig().MyAnimeList = config.MyAnimeListConfig{AccessToken: "token", UserID: 1}
+
+	mal := &fakeMAL{statuses: make(map[string]ListStatus)}
+	transport := httpClient.Transport
+	t.Cleanup(func() { httpClient.Transport = transport })
+	httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if mal.offline {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("network is unreachable")}
+		}
+		recorder := httptest.NewRecorder()
+		mal.ServeHTTP(recorder, req)
+		return recorder.Result(), nil
+	})
+	offline.Store(false)
+
+	return mal
+}
+
+// set stores a title's list status as last changed at the given tick
+func (m *fakeMAL) set(key string, status ListStatus, tick int) {
+	status.UpdatedAt = fakeTime(tick)
+	m.statuses[key] = status
+	m.clock = max(m.clock, tick)
+}
+
+func fakeTime(tick int) string {
+	return fmt.Sprintf("2024-01-01T00:00:%02d+00:00", tick)
+}
+
+func (m *fakeMAL) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// e.g. /v2/anime/1 or /v2/anime/1/my_list_status
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/v2/"), "/")
+	if len(parts) == 1 && req.Method == "GET" {
+		var page malPage[titleNode]
+		for _, title := range m.titles {
+			page.Data = append(page.Data, titleNode{Node: title})
+		}
+		json.NewEncoder(w).Encode(page)
+		return
+	}
+	if len(parts) < 2 {
+		http.NotFound(w, req)
+		return
+	}
+	key := parts[0] + "/" + parts[1]
+
+	switch req.Method {
+	case "GET":
+		response := map[string]interface{}{}
+		if status, ok := m.statuses[key]; ok {
+			response["my_list_status"] = status
+		}
+		json.NewEncoder(w).Encode(response)
+	case "PATCH":
+		if m.onPatch != nil {
+			m.onPatch()
+		}
+		req.ParseForm()
+		fields := make(map[string]string)
+		for name := range req.PostForm {
+			fields[name] = req.PostForm.Get(name)
+		}
+
+		m.clock++
+		status := ListUpdate{Fields: fields}.Apply(m.statuses[key])
+		status.UpdatedAt = fakeTime(m.clock)
+		m.statuses[key] = status
+		m.patches = append(m.patches, key+" "+ListUpdate{Title: key, Fields: fields}.String())
+		json.NewEncoder(w).Encode(status)
+	default:
+		http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+	}
+}
+
+func episodes(malID, n int, base string) ListUpdate {
+	return ListUpdate{
+		MediaType:     "anime",
+		MALID:         malID,
+		Fields:        map[string]string{"num_watched_episodes": strconv.Itoa(n)},
+		BaseUpdatedAt: base,
+	}
+}
+
+// queue appends updates to the journal as if they were made offline
+func queue(t *testing.T, updates ...ListUpdate) {
+	t.Helper()
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	for _, update := range updates {
+		pending, err := loadJournal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := queueUpdate(pending, update); !errors.Is(err, ErrUpdateQueued) {
+			t.Fatal(err)
+		}
+	}
+}
+
+func journal(t *testing.T) []PendingUpdate {
+	t.Helper()
+	pending, err := PendingUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pending
+}
+
+func TestSyncPendingReplaysInOrder(t *testing.T) {
+	mal := stubMAL(t)
+	queue(t, episodes(1, 1, ""), episodes(2, 1, ""), episodes(1, 2, ""))
+
+	result, err := SyncPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != (SyncResult{Applied: 3}) {
+		t.Errorf("result = %+v, want 3 applied", result)
+	}
+	want := []string{
+		"anime/1 anime/1: num_watched_episodes=1",
+		"anime/2 anime/2: num_watched_episodes=1",
+		"anime/1 anime/1: num_watched_episodes=2",
+	}
+	if strings.Join(mal.patches, "\n") != strings.Join(want, "\n") {
+		t.Errorf("patches:\n%s\nwant:\n%s", strings.Join(mal.patches, "\n"), strings.Join(want, "\n"))
+	}
+	if got := mal.statuses["anime/1"].NumEpisodesWatched; got != 2 {
+		t.Errorf("episodes watched = %d, want 2", got)
+	}
+	if pending := journal(t); len(pending) != 0 {
+		t.Errorf("%d updates left in the journal", len(pending))
+	}
+}
+
+func TestSyncPendingMarksConflicts(t *testing.T) {
+	mal := stubMAL(t)
+	mal.set("anime/1", ListStatus{NumEpisodesWatched: 3}, 2)
+	// Made against the entry as it was before the change at tick 2
+	queue(t, episodes(1, 2, fakeTime(1)))
+
+	result, err := SyncPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != (SyncResult{Conflicts: 1, Remaining: 1}) {
+		t.Errorf("result = %+v, want 1 conflict remaining", result)
+	}
+	if len(mal.patches) != 0 {
+		t.Errorf("conflicting update was sent: %v", mal.patches)
+	}
+	pending := journal(t)
+	if len(pending) != 1 || !pending[0].Conflict {
+		t.Fatalf("journal = %+v, want the update marked as conflicting", pending)
+	}
+
+	// Forcing the update sends it anyway
+	result, err = SyncPending(pending[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (SyncResult{Applied: 1}) {
+		t.Errorf("forced result = %+v, want 1 applied", result)
+	}
+	if got := mal.statuses["anime/1"].NumEpisodesWatched; got != 2 {
+		t.Errorf("episodes watched = %d, want 2", got)
+	}
+}
+
+func TestSyncPendingBlocksLaterUpdatesToTheSameTitle(t *testing.T) {
+	mal := stubMAL(t)
+	mal.set("anime/1", ListStatus{NumEpisodesWatched: 3}, 2)
+	queue(t, episodes(1, 2, fakeTime(1)), episodes(2, 1, ""), episodes(1, 4, ""))
+
+	result, err := SyncPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != (SyncResult{Applied: 1, Conflicts: 1, Remaining: 2}) {
+		t.Errorf("result = %+v, want 1 applied and 1 conflict with 2 remaining", result)
+	}
+	if len(mal.patches) != 1 || !strings.HasPrefix(mal.patches[0], "anime/2 ") {
+		t.Errorf("patches = %v, want only the update to the other title", mal.patches)
+	}
+
+	pending := journal(t)
+	if len(pending) != 2 || pending[0].Update.MALID != 1 || pending[1].Update.MALID != 1 {
+		t.Fatalf("journal = %+v, want both updates to title 1 in order", pending)
+	}
+	if !pending[0].Conflict || pending[1].Conflict {
+		t.Errorf("conflict flags = %v, %v, want only the first one set", pending[0].Conflict, pending[1].Conflict)
+	}
+}
+
+func TestSyncPendingRebasesAfterApplying(t *testing.T) {
+	mal := stubMAL(t)
+	mal.set("anime/1", ListStatus{NumEpisodesWatched: 1}, 1)
+	// Two offline changes in a row, both against the entry MAL last confirmed
+	queue(t, episodes(1, 2, fakeTime(1)), episodes(1, 3, fakeTime(1)))
+
+	result, err := SyncPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != (SyncResult{Applied: 2}) {
+		t.Errorf("result = %+v, want 2 applied", result)
+	}
+	if got := mal.statuses["anime/1"].NumEpisodesWatched; got != 3 {
+		t.Errorf("episodes watched = %d, want 3", got)
+	}
+}
+
+func TestSyncPendingKeepsUpdatesQueuedDuringSync(t *testing.T) {
+	mal := stubMAL(t)
+	mal.set("anime/1", ListStatus{NumEpisodesWatched: 1}, 1)
+	queue(t, episodes(1, 2, fakeTime(1)), episodes(2, 1, ""))
+
+	// While the first update is being sent, the user makes two more
+	// changes: one to the same title, still based on the old entry, and
+	// one to another title
+	mal.onPatch = func() {
+		mal.onPatch = nil
+		queue(t, episodes(1, 3, fakeTime(1)), episodes(3, 1, ""))
+	}
+
+	result, err := SyncPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Applied != 2 || result.Remaining != 2 {
+		t.Errorf("result = %+v, want 2 applied and 2 remaining", result)
+	}
+	pending := journal(t)
+	if len(pending) != 2 || pending[0].Update.MALID != 1 || pending[1].Update.MALID != 3 {
+		t.Fatalf("journal = %+v, want the updates queued during the sync", pending)
+	}
+	if base := pending[0].Update.BaseUpdatedAt; base != mal.statuses["anime/1"].UpdatedAt {
+		t.Errorf("base of the queued update = %s, want it rebased on %s", base, mal.statuses["anime/1"].UpdatedAt)
+	}
+
+	// The rebased update then applies without a conflict
+	result, err = SyncPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (SyncResult{Applied: 2}) {
+		t.Errorf("second result = %+v, want 2 applied", result)
+	}
+}
+
+func TestSubmitListUpdateQueues(t *testing.T) {
+	mal := stubMAL(t)
+
+	mal.offline = true
+	if _, err := SubmitListUpdate(episodes(1, 1, "")); !errors.Is(err, ErrUpdateQueued) {
+		t.Fatalf("offline submit returned %v, want ErrUpdateQueued", err)
+	}
+	if !IsOffline() {
+		t.Error("not reported offline after a network error")
+	}
+
+	// Back online, a later change to the same title must wait its turn
+	mal.offline = false
+	if _, err := SubmitListUpdate(episodes(1, 2, "")); !errors.Is(err, ErrUpdateQueued) {
+		t.Fatalf("submit behind a pending update returned %v, want ErrUpdateQueued", err)
+	}
+	// Other titles go straight through
+	if _, err := SubmitListUpdate(episodes(2, 1, "")); err != nil {
+		t.Fatalf("submit of another title: %v", err)
+	}
+
+	if len(mal.patches) != 1 || !strings.HasPrefix(mal.patches[0], "anime/2 ") {
+		t.Errorf("patches = %v, want only the other title", mal.patches)
+	}
+	if pending := journal(t); len(pending) != 2 {
+		t.Errorf("%d updates in the journal, want 2", len(pending))
+	}
+}
@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ListStatus is the user's progress on a title, as returned by the MAL API
+// in my_list_status. Anime and manga share it, leaving the other's fields empty.
+type ListStatus struct {
+	Status             string   `json:"status"`
+	Score              int      `json:"score"`
+	NumEpisodesWatched int      `json:"num_episodes_watched,omitempty"`
+	IsRewatching       bool     `json:"is_rewatching,omitempty"`
+	NumTimesRewatched  int      `json:"num_times_rewatched,omitempty"`
+	RewatchValue       int      `json:"rewatch_value,omitempty"`
+	NumChaptersRead    int      `json:"num_chapters_read,omitempty"`
+	NumVolumesRead     int      `json:"num_volumes_read,omitempty"`
+	IsRereading        bool     `json:"is_rereading,omitempty"`
+	NumTimesReread     int      `json:"num_times_reread,omitempty"`
+	RereadValue        int      `json:"reread_value,omitempty"`
+	StartDate          string   `json:"start_date,omitempty"`
+	FinishDate         string   `json:"finish_date,omitempty"`
+	Priority           int      `json:"priority,omitempty"`
+	Tags               []string `json:"tags,omitempty"`
+	Comments           string   `json:"comments,omitempty"`
+	UpdatedAt          string   `json:"updated_at,omitempty"`
+}
+
+// Progress returns the number of episodes watched or chapters read
+func (s ListStatus) Progress() int {
+	if s.NumEpisodesWatched > 0 {
+		return s.NumEpisodesWatched
+	}
+	return s.NumChaptersRead
+}
+
+// ListUpdate is a change to the user's list entry for a title. Fields holds
+// the my_list_status form parameters, e.g. "status", "score" or
+// "num_watched_episodes".
+type ListUpdate struct {
+	MediaType string            `json:"media_type"`
+	MALID     int               `json:"mal_id"`
+	Title     string            `json:"title,omitempty"`
+	Fields    map[string]string `json:"fields"`
+
+	// BaseUpdatedAt is the updated_at of the entry the change was made
+	// against, used to detect edits made elsewhere while we were offline
+	BaseUpdatedAt string `json:"base_updated_at,omitempty"`
+}
+
+// String describes the change, e.g. "Frieren: num_watched_episodes=5"
+func (u ListUpdate) String() string {
+	keys := make([]string, 0, len(u.Fields))
+	for key, value := range u.Fields {
+		keys = append(keys, key+"="+value)
+	}
+	sort.Strings(keys)
+
+	title := u.Title
+	if title == "" {
+		title = fmt.Sprintf("%s #%d", u.MediaType, u.MALID)
+	}
+
+	return fmt.Sprintf("%s: %s", title, strings.Join(keys, ", "))
+}
+
+// Apply returns status with the update's fields applied to it
+func (u ListUpdate) Apply(status ListStatus) ListStatus {
+	for key, value := range u.Fields {
+		n, _ := strconv.Atoi(value)
+		switch key {
+		case "status":
+			status.Status = value
+		case "score":
+			status.Score = n
+		case "num_watched_episodes":
+			status.NumEpisodesWatched = n
+		case "num_chapters_read":
+			status.NumChaptersRead = n
+		case "num_volumes_read":
+			status.NumVolumesRead = n
+		case "is_rewatching":
+			status.IsRewatching = value == "true"
+		case "is_rereading":
+			status.IsRereading = value == "true"
+		case "num_times_rewatched":
+			status.NumTimesRewatched = n
+		case "num_times_reread":
+			status.NumTimesReread = n
+		case "start_date":
+			status.StartDate = value
+		case "finish_date":
+			status.FinishDate = value
+		case "priority":
+			status.Priority = n
+		case "comments":
+			status.Comments = value
+		case "tags":
+			status.Tags = strings.Split(value, ",")
+		}
+	}
+	return status
+}
+
+// GetListStatus fetches the user's current list entry for a title. It
+// returns nil when the title isn't on the user's list.
+func GetListStatus(mediaType string, malID int) (*ListStatus, error) {
+	req, err := newMALRequest("GET", fmt.Sprintf("/%s/%d?fields=my_list_status", mediaType, malID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		MyListStatus *ListStatus `json:"my_list_status"`
+	}
+	if err := doJSON(req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.MyListStatus, nil
+}
+
+// UpdateListStatus sends the update to MAL and returns the resulting list entry
+func UpdateListStatus(update ListUpdate) (*ListStatus, error) {
+	data := url.Values{}
+	for key, value := range update.Fields {
+		data.Set(key, value)
+	}
+
+	req, err := newMALRequest("PATCH", fmt.Sprintf("/%s/%d/my_list_status", update.MediaType, update.MALID), strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var status ListStatus
+	if err := doJSON(req, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
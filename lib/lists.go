@@ -0,0 +1,253 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fullSyncInterval is how often the whole list is refetched, which is the
+// only way to notice entries removed on MAL
+const fullSyncInterval = 24 * time.Hour
+
+// AnimeStatuses and MangaStatuses are the list statuses MAL accepts
+var (
+	AnimeStatuses = []string{"watching", "completed", "on_hold", "dropped", "plan_to_watch"}
+	MangaStatuses = []string{"reading", "completed", "on_hold", "dropped", "plan_to_read"}
+)
+
+// ListEntry is a title on the user's list together with its list status
+type ListEntry struct {
+	Node       Title      `json:"node"`
+	ListStatus ListStatus `json:"list_status"`
+}
+
+// UserList is the local mirror of the user's anime or manga list
+type UserList struct {
+	MediaType    string      `json:"media_type"`
+	Entries      []ListEntry `json:"entries"`
+	SyncedAt     time.Time   `json:"synced_at"`
+	FullSyncedAt time.Time   `json:"full_synced_at"`
+}
+
+var listMu sync.Mutex
+
+// Find returns the entry for a title, or nil when it isn't on the list
+func (l *UserList) Find(malID int) *ListEntry {
+	for i := range l.Entries {
+		if l.Entries[i].Node.ID == malID {
+			return &l.Entries[i]
+		}
+	}
+	return nil
+}
+
+// WithStatus returns the entries with the given list status, or all entries
+// when status is empty
+func (l *UserList) WithStatus(status string) []ListEntry {
+	if status == "" {
+		return l.Entries
+	}
+
+	entries := make([]ListEntry, 0, len(l.Entries))
+	for _, entry := range l.Entries {
+		if entry.ListStatus.Status == status {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// LoadList reads the local mirror of the user's list without touching the
+// network. It returns an empty list if it was never synced.
+func LoadList(mediaType string) (*UserList, error) {
+	listMu.Lock()
+	defer listMu.Unlock()
+
+	return loadList(mediaType)
+}
+
+// SyncList refreshes the local mirror from MAL. Entries are fetched most
+// recently updated first, so only the ones changed since the last sync are
+// downloaded, except for a periodic full refresh.
+func SyncList(mediaType string) (*UserList, error) {
+	return syncList(mediaType, false)
+}
+
+// RefreshList refetches the whole list from MAL into the local mirror
+func RefreshList(mediaType string) (*UserList, error) {
+	return syncList(mediaType, true)
+}
+
+// syncList fetches the list from MAL without holding listMu, so reading the
+// mirror never waits on the network, and only locks it to merge and save
+func syncList(mediaType string, full bool) (*UserList, error) {
+	listMu.Lock()
+	list, err := loadList(mediaType)
+	listMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	full = full || time.Since(list.FullSyncedAt) > fullSyncInterval
+	latest := ""
+	if !full {
+		for _, entry := range list.Entries {
+			if entry.ListStatus.UpdatedAt > latest {
+				latest = entry.ListStatus.UpdatedAt
+			}
+		}
+	}
+
+	path := fmt.Sprintf("/users/@me/%slist?fields=%s,%s&sort=list_updated_at&limit=1000&nsfw=true",
+		mediaType, listStatusFields(mediaType), titleFields(mediaType))
+
+	var fetched []ListEntry
+	err = fetchMALPages(path, func(entries []ListEntry) bool {
+		for _, entry := range entries {
+			// Timestamps are RFC 3339 in UTC, so they compare as strings
+			if latest != "" && entry.ListStatus.UpdatedAt <= latest {
+				return false
+			}
+			fetched = append(fetched, entry)
+		}
+		return true
+	})
+	if err != nil {
+		if isNetworkError(err) {
+			offline.Store(true)
+		}
+		return list, err
+	}
+	offline.Store(false)
+
+	listMu.Lock()
+	defer listMu.Unlock()
+
+	// Reload, as updates may have been recorded while fetching
+	list, err = loadList(mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	if full {
+		previous := list.Entries
+		list.Entries = nil
+		for _, entry := range fetched {
+			list.put(entry)
+		}
+		for _, entry := range previous {
+			if current := list.Find(entry.Node.ID); current != nil && entry.ListStatus.UpdatedAt > current.ListStatus.UpdatedAt {
+				*current = entry
+			}
+		}
+		list.FullSyncedAt = time.Now()
+	} else {
+		for _, entry := range fetched {
+			if existing := list.Find(entry.Node.ID); existing == nil || entry.ListStatus.UpdatedAt >= existing.ListStatus.UpdatedAt {
+				list.put(entry)
+			}
+		}
+	}
+	list.SyncedAt = time.Now()
+	list.sort()
+
+	return list, saveList(list)
+}
+
+// UpdateLocalEntry records a list status confirmed by MAL in the local mirror
+func UpdateLocalEntry(mediaType string, malID int, status ListStatus) error {
+	listMu.Lock()
+	defer listMu.Unlock()
+
+	list, err := loadList(mediaType)
+	if err != nil {
+		return err
+	}
+
+	entry := list.Find(malID)
+	if entry == nil {
+		return nil
+	}
+	entry.ListStatus = status
+
+	return saveList(list)
+}
+
+// listStatusFields requests the full list status, as the list endpoints only
+// return the basic fields by default
+func listStatusFields(mediaType string) string {
+	fields := "list_status{start_date,finish_date,priority,tags,comments,updated_at"
+	if mediaType == "manga" {
+		return fields + ",num_times_reread,reread_value}"
+	}
+	return fields + ",num_times_rewatched,rewatch_value}"
+}
+
+func (l *UserList) put(entry ListEntry) {
+	if existing := l.Find(entry.Node.ID); existing != nil {
+		*existing = entry
+		return
+	}
+	l.Entries = append(l.Entries, entry)
+}
+
+func (l *UserList) sort() {
+	sort.SliceStable(l.Entries, func(i, j int) bool {
+		return l.Entries[i].Node.Title < l.Entries[j].Node.Title
+	})
+}
+
+func listPath(mediaType string) (string, error) {
+	userDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userDir, "lists", mediaType+".json"), nil
+}
+
+// loadList reads the local list. Callers must hold listMu.
+func loadList(mediaType string) (*UserList, error) {
+	path, err := listPath(mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &UserList{MediaType: mediaType}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return list, nil
+		}
+		return nil, fmt.Errorf("failed to read %s list: %w", mediaType, err)
+	}
+
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("failed to decode %s list: %w", mediaType, err)
+	}
+
+	return list, nil
+}
+
+// saveList writes the local list. Callers must hold listMu.
+func saveList(list *UserList) error {
+	path, err := listPath(list.MediaType)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s list: %w", list.MediaType, err)
+	}
+
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s list: %w", list.MediaType, err)
+	}
+
+	return nil
+}
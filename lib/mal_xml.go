@@ -0,0 +1,136 @@
+package lib
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// The types below mirror the XML files produced by MAL's list export and
+// accepted by its importer at https://myanimelist.net/import.php
+
+type malXMLList struct {
+	XMLName xml.Name      `xml:"myanimelist"`
+	MyInfo  malXMLInfo    `xml:"myinfo"`
+	Anime   []malXMLAnime `xml:"anime"`
+	Manga   []malXMLManga `xml:"manga"`
+}
+
+type malXMLInfo struct {
+	UserID         int    `xml:"user_id"`
+	UserName       string `xml:"user_name"`
+	UserExportType int    `xml:"user_export_type"`
+	Total          int    `xml:"user_total_anime,omitempty"`
+	TotalManga     int    `xml:"user_total_manga,omitempty"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+type malXMLAnime struct {
+	SeriesID        int    `xml:"series_animedb_id"`
+	SeriesTitle     cdata  `xml:"series_title"`
+	SeriesType      string `xml:"series_type"`
+	SeriesEpisodes  int    `xml:"series_episodes"`
+	MyID            int    `xml:"my_id"`
+	WatchedEpisodes int    `xml:"my_watched_episodes"`
+	StartDate       string `xml:"my_start_date"`
+	FinishDate      string `xml:"my_finish_date"`
+	Rated           string `xml:"my_rated"`
+	Score           int    `xml:"my_score"`
+	Storage         string `xml:"my_storage"`
+	StorageValue    string `xml:"my_storage_value"`
+	Status          string `xml:"my_status"`
+	Comments        cdata  `xml:"my_comments"`
+	TimesWatched    int    `xml:"my_times_watched"`
+	RewatchValue    string `xml:"my_rewatch_value"`
+	Priority        string `xml:"my_priority"`
+	Tags            cdata  `xml:"my_tags"`
+	Rewatching      int    `xml:"my_rewatching"`
+	RewatchingEp    int    `xml:"my_rewatching_ep"`
+	Discuss         int    `xml:"my_discuss"`
+	SNS             string `xml:"my_sns"`
+	UpdateOnImport  int    `xml:"update_on_import"`
+}
+
+type malXMLManga struct {
+	MangaID        int    `xml:"manga_mangadb_id"`
+	MangaTitle     cdata  `xml:"manga_title"`
+	MangaVolumes   int    `xml:"manga_volumes"`
+	MangaChapters  int    `xml:"manga_chapters"`
+	MyID           int    `xml:"my_id"`
+	ReadVolumes    int    `xml:"my_read_volumes"`
+	ReadChapters   int    `xml:"my_read_chapters"`
+	StartDate      string `xml:"my_start_date"`
+	FinishDate     string `xml:"my_finish_date"`
+	ScanGroup      cdata  `xml:"my_scanalation_group"`
+	Score          int    `xml:"my_score"`
+	Storage        string `xml:"my_storage"`
+	RetailVolumes  int    `xml:"my_retail_volumes"`
+	Status         string `xml:"my_status"`
+	Comments       cdata  `xml:"my_comments"`
+	TimesRead      int    `xml:"my_times_read"`
+	Tags           cdata  `xml:"my_tags"`
+	Priority       string `xml:"my_priority"`
+	RereadValue    string `xml:"my_reread_value"`
+	Rereading      int    `xml:"my_rereading"`
+	Discuss        int    `xml:"my_discuss"`
+	SNS            string `xml:"my_sns"`
+	UpdateOnImport int    `xml:"update_on_import"`
+}
+
+// malXMLStatuses maps API list statuses to the names used in MAL's XML
+var malXMLStatuses = map[string]string{
+	"watching":      "Watching",
+	"reading":       "Reading",
+	"completed":     "Completed",
+	"on_hold":       "On-Hold",
+	"dropped":       "Dropped",
+	"plan_to_watch": "Plan to Watch",
+	"plan_to_read":  "Plan to Read",
+}
+
+var malXMLPriorities = []string{"LOW", "MEDIUM", "HIGH"}
+
+// malXMLRepeatValues maps rewatch_value/reread_value to their XML names
+var malXMLRepeatValues = []string{"", "Very Low", "Low", "Medium", "High", "Very High"}
+
+// malXMLMediaTypes maps API media types to the series_type used in MAL's XML
+var malXMLMediaTypes = map[string]string{
+	"tv":         "TV",
+	"ova":        "OVA",
+	"movie":      "Movie",
+	"special":    "Special",
+	"ona":        "ONA",
+	"music":      "Music",
+	"tv_special": "TV Special",
+	"cm":         "CM",
+	"pv":         "PV",
+}
+
+// malXMLDate converts an API date, which may be just a year or year and
+// month, to MAL's XML format, where unknown parts are zero
+func malXMLDate(date string) string {
+	if date == "" {
+		return "0000-00-00"
+	}
+	parts := strings.Split(date, "-")
+	for len(parts) < 3 {
+		parts = append(parts, "00")
+	}
+	return strings.Join(parts, "-")
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func valueAt(values []string, i int) string {
+	if i < 0 || i >= len(values) {
+		return ""
+	}
+	return values[i]
+}
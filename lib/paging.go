@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"strings"
+	"yato/config"
+)
+
+// malPage is one page of a paginated MAL API response
+type malPage[T any] struct {
+	Data   []T `json:"data"`
+	Paging struct {
+		Previous string `json:"previous"`
+		Next     string `json:"next"`
+	} `json:"paging"`
+}
+
+// fetchMALPages requests path and follows the paging links, passing each page
+// of results to visit until there are no more pages or visit returns false
+func fetchMALPages[T any](path string, visit func([]T) bool) error {
+	for path != "" {
+		req, err := newMALRequest("GET", path, nil)
+		if err != nil {
+			return err
+		}
+
+		var page malPage[T]
+		if err := doJSON(req, &page); err != nil {
+			return err
+		}
+
+		if !visit(page.Data) {
+			return nil
+		}
+
+		path = strings.TrimPrefix(page.Paging.Next, config.MALAPIBaseURL)
+	}
+
+	return nil
+}
+
+// fetchMALPage requests a single page, returning its results and whether
+// there are more after it
+func fetchMALPage[T any](path string) ([]T, bool, error) {
+	req, err := newMALRequest("GET", path, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var page malPage[T]
+	if err := fetchJSON(req, &page); err != nil {
+		return nil, false, err
+	}
+
+	return page.Data, page.Paging.Next != "", nil
+}
@@ -0,0 +1,58 @@
+package lib
+
+import (
+	"context"
+	"sync"
+)
+
+// Prefetcher keeps the covers around a scrolling window on disk, so they are
+// already cached by the time they appear on screen
+type Prefetcher struct {
+	cache  *ImageCache
+	margin int
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// NewPrefetcher creates a Prefetcher that fetches margin rows ahead of and
+// behind the visible window
+func (c *ImageCache) NewPrefetcher(margin int) *Prefetcher {
+	return &Prefetcher{cache: c, margin: margin}
+}
+
+// SetWindow prefetches items[first:last] followed by the rows after and then
+// before it, cancelling whatever is still queued for the previous window
+func (p *Prefetcher) SetWindow(items []ImageRequest, first, last int) {
+	first = max(0, min(first, len(items)))
+	last = max(first, min(last, len(items)))
+
+	requests := make([]ImageRequest, 0, last-first+2*p.margin)
+	requests = append(requests, items[first:last]...)
+	requests = append(requests, items[last:min(len(items), last+p.margin)]...)
+	for i := first - 1; i >= max(0, first-p.margin); i-- {
+		requests = append(requests, items[i])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p.mu.Lock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.cancel = cancel
+	p.mu.Unlock()
+
+	p.cache.Prefetch(ctx, requests...)
+}
+
+// Stop cancels any queued prefetches
+func (p *Prefetcher) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+}
@@ -0,0 +1,37 @@
+package lib
+
+import "fmt"
+
+// RankingTypes lists the ranking_type values MAL accepts per media type
+var RankingTypes = map[string][]string{
+	"anime": {"all", "airing", "upcoming", "tv", "ova", "movie", "special", "bypopularity", "favorite"},
+	"manga": {"all", "manga", "novels", "lightnovels", "oneshots", "doujin", "manhwa", "manhua", "bypopularity", "favorite"},
+}
+
+// rankingNode is an entry of MAL's ranking responses
+type rankingNode struct {
+	Node    Title `json:"node"`
+	Ranking struct {
+		Rank int `json:"rank"`
+	} `json:"ranking"`
+}
+
+// GetRanking fetches a page of the anime or manga ranking, returning the
+// titles with their Rank set and whether there are more after them
+func GetRanking(mediaType, rankingType string, offset, limit int) ([]Title, bool, error) {
+	path := fmt.Sprintf("/%s/ranking?ranking_type=%s&offset=%d&limit=%d&fields=%s&nsfw=true",
+		mediaType, rankingType, offset, limit, titleFields(mediaType))
+
+	nodes, hasNext, err := fetchMALPage[rankingNode](path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	titles := make([]Title, len(nodes))
+	for i, node := range nodes {
+		titles[i] = node.Node
+		titles[i].Rank = node.Ranking.Rank
+	}
+
+	return titles, hasNext, nil
+}
@@ -1,34 +1,55 @@
 package lib
 
-import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"yato/config"
-)
+import "fmt"
+
+type ImageURLs struct {
+	ImageURL      string `json:"image_url"`
+	SmallImageURL string `json:"small_image_url"`
+	LargeImageURL string `json:"large_image_url"`
+}
+
+type Images struct {
+	JPG  ImageURLs `json:"jpg"`
+	WebP ImageURLs `json:"webp"`
+}
+
+// URL returns the image URL for size ("small", "large" or anything else for
+// the default size), using the usually smaller WebP variant when preferWebP
+// is set and Jikan provides one
+func (i Images) URL(size string, preferWebP bool) string {
+	if preferWebP {
+		if url := i.WebP.url(size); url != "" {
+			return url
+		}
+	}
+	return i.JPG.url(size)
+}
+
+func (u ImageURLs) url(size string) string {
+	switch size {
+	case "small":
+		return u.SmallImageURL
+	case "large":
+		return u.LargeImageURL
+	default:
+		return u.ImageURL
+	}
+}
+
+// JikanEntry is the short form of an anime or manga embedded in Jikan responses
+type JikanEntry struct {
+	MALId  int    `json:"mal_id"`
+	URL    string `json:"url"`
+	Images Images `json:"images"`
+	Title  string `json:"title"`
+}
 
 type Recommendation struct {
-	MALId string `json:"mal_id"`
-	URL   string `json:"url"`
-	Entry []struct {
-		MALId  int    `json:"mal_id"`
-		URL    string `json:"url"`
-		Images struct {
-			JPG struct {
-				ImageURL      string `json:"image_url"`
-				SmallImageURL string `json:"small_image_url"`
-				LargeImageURL string `json:"large_image_url"`
-			} `json:"jpg"`
-			WebP struct {
-				ImageURL      string `json:"image_url"`
-				SmallImageURL string `json:"small_image_url"`
-				LargeImageURL string `json:"large_image_url"`
-			} `json:"webp"`
-		} `json:"images"`
-		Title string `json:"title"`
-	} `json:"entry"`
-	Content string `json:"content"`
-	Date    string `json:"date"`
+	MALId   string       `json:"mal_id"`
+	URL     string       `json:"url"`
+	Entry   []JikanEntry `json:"entry"`
+	Content string       `json:"content"`
+	Date    string       `json:"date"`
 	User    struct {
 		URL      string `json:"url"`
 		Username string `json:"username"`
@@ -44,27 +65,14 @@ type recommendationsResponse struct {
 }
 
 func getRecentRecommendations(mediaType string) ([]Recommendation, error) {
-	url := fmt.Sprintf("%s/recommendations/%s", config.JikanAPIBaseURL, mediaType)
-
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := newJikanRequest(fmt.Sprintf("/recommendations/%s", mediaType))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, err
 	}
 
 	var response recommendationsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := fetchJSON(req, &response); err != nil {
+		return nil, err
 	}
 
 	return response.Data, nil
@@ -0,0 +1,176 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"yato/config"
+)
+
+// responseTTLs lists how long responses stay fresh, matched by URL prefix.
+// Responses past their TTL are revalidated, and still used while offline.
+var responseTTLs = []struct {
+	prefix string
+	ttl    time.Duration
+}{
+	{config.JikanAPIBaseURL + "/recommendations", time.Hour},
+	{config.MALAPIBaseURL + "/users/@me", time.Hour},
+	{config.MALAPIBaseURL + "/anime/season", 6 * time.Hour},
+	{config.MALAPIBaseURL + "/anime/ranking", 6 * time.Hour},
+	{config.MALAPIBaseURL + "/manga/ranking", 6 * time.Hour},
+}
+
+const defaultResponseTTL = 15 * time.Minute
+
+// cachedResponse is a response body stored on disk with its validators
+type cachedResponse struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	Body         []byte    `json:"body"`
+}
+
+var (
+	responseCacheDir     string
+	responseCacheDirOnce sync.Once
+
+	offline atomic.Bool
+)
+
+// IsOffline reports whether the last API request failed to reach the server
+// and was answered from the response cache instead
+func IsOffline() bool {
+	return offline.Load()
+}
+
+func responseTTL(url string) time.Duration {
+	for _, t := range responseTTLs {
+		if strings.HasPrefix(url, t.prefix) {
+			return t.ttl
+		}
+	}
+	return defaultResponseTTL
+}
+
+// responseDir returns the directory cached responses are stored in, or an
+// empty string when there is no cache directory
+func responseDir() string {
+	responseCacheDirOnce.Do(func() {
+		if dir, err := config.CacheDir(); err == nil {
+			responseCacheDir = filepath.Join(dir, "http")
+		}
+	})
+	return responseCacheDir
+}
+
+// responseIdentity returns who the response to req belongs to, so different
+// accounts never share responses. Authenticated requests are keyed on the
+// MAL user rather than the token, which would orphan the cache whenever it
+// is refreshed. The token is only used until the user is known.
+func responseIdentity(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	if auth == "" {
+		return ""
+	}
+	if id := config.GetConfig().MyAnimeList.UserID; id != 0 {
+		return "user:" + strconv.Itoa(id)
+	}
+	return auth
+}
+
+// responseCachePath returns where the response for url is stored
+func responseCachePath(url, identity string) string {
+	dir := responseDir()
+	if dir == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(url + "\n" + identity))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// pruneResponses removes the responses stored longer than maxAge ago,
+// returning how many were removed and the bytes freed
+func pruneResponses(maxAge time.Duration) (int, int64, error) {
+	dir := responseDir()
+	if dir == "" || maxAge <= 0 {
+		return 0, 0, nil
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("failed to read response cache: %w", err)
+	}
+
+	removed, freed := 0, int64(0)
+	cutoff := time.Now().Add(-maxAge)
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || info.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file.Name())); err != nil {
+			return removed, freed, fmt.Errorf("failed to prune response cache: %w", err)
+		}
+		removed++
+		freed += info.Size()
+	}
+
+	return removed, freed, nil
+}
+
+// clearResponses removes every cached response
+func clearResponses() error {
+	dir := responseDir()
+	if dir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear response cache: %w", err)
+	}
+	return nil
+}
+
+func loadResponse(path string) *cachedResponse {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+
+	return &cached
+}
+
+func storeResponse(path string, cached *cachedResponse) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data, 0600)
+}
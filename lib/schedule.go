@@ -0,0 +1,158 @@
+package lib
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// jst is the time zone MAL broadcast times are given in
+var jst = time.FixedZone("JST", 9*60*60)
+
+const week = 7 * 24 * time.Hour
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Airing is a title on the user's list with its estimated airing progress
+type Airing struct {
+	Entry ListEntry
+	// Aired is the number of episodes aired so far, or 0 when unknown
+	Aired int
+	// Next is when the next episode airs, or zero when none are expected
+	Next time.Time
+	// First is when the first episode aired or airs, or zero when unknown
+	First time.Time
+}
+
+// NextEpisode returns the number of the episode airing at Next, or 0 when
+// it isn't known
+func (a Airing) NextEpisode() int {
+	if a.First.IsZero() {
+		return 0
+	}
+	return a.Aired + 1
+}
+
+// Behind returns how many aired episodes the user hasn't watched yet. It
+// returns false when the episodes aired aren't known, for titles without a
+// start date.
+func (a Airing) Behind() (int, bool) {
+	if a.First.IsZero() && a.Aired == 0 {
+		return 0, false
+	}
+	return max(0, a.Aired-a.Entry.ListStatus.NumEpisodesWatched), true
+}
+
+// slot parses a broadcast into its weekday and time of day
+func (b *Broadcast) slot() (time.Weekday, time.Duration, bool) {
+	if b == nil {
+		return 0, 0, false
+	}
+	weekday, ok := weekdays[strings.ToLower(b.DayOfTheWeek)]
+	if !ok {
+		return 0, 0, false
+	}
+	start, err := time.Parse("15:04", b.StartTime)
+	if err != nil {
+		return 0, 0, false
+	}
+	return weekday, time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute, true
+}
+
+// nextSlot returns the first broadcast slot at or after t
+func nextSlot(t time.Time, weekday time.Weekday, offset time.Duration) time.Time {
+	t = t.In(jst)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, jst)
+	slot := day.AddDate(0, 0, (int(weekday)-int(day.Weekday())+7)%7).Add(offset)
+	if slot.Before(t) {
+		slot = slot.AddDate(0, 0, 7)
+	}
+	return slot
+}
+
+// EstimateAiring works out how many episodes of an anime have aired by now
+// and when the next one airs, assuming one episode a week from its start
+// date. Breaks in the broadcast aren't known to MAL, so they aren't
+// accounted for. It returns false for titles without a usable broadcast.
+func EstimateAiring(title Title, now time.Time) (Airing, bool) {
+	weekday, offset, ok := title.Broadcast.slot()
+	if !ok {
+		return Airing{}, false
+	}
+	airing := Airing{Entry: ListEntry{Node: title}}
+
+	switch title.Status {
+	case "finished_airing":
+		airing.Aired = title.NumEpisodes
+		return airing, true
+	case "currently_airing", "not_yet_aired":
+	default:
+		return airing, false
+	}
+
+	start, err := time.ParseInLocation("2006-01-02", title.StartDate, jst)
+	if err != nil {
+		// Without a full start date only the next slot is known
+		if title.Status == "currently_airing" {
+			airing.Next = nextSlot(now, weekday, offset)
+		}
+		return airing, true
+	}
+
+	airing.First = nextSlot(start, weekday, offset)
+	if now.Before(airing.First) {
+		airing.Next = airing.First
+		return airing, true
+	}
+
+	airing.Aired = int(now.Sub(airing.First)/week) + 1
+	airing.Next = airing.First.AddDate(0, 0, 7*airing.Aired)
+	if title.NumEpisodes > 0 && airing.Aired >= title.NumEpisodes {
+		airing.Aired = title.NumEpisodes
+		airing.Next = time.Time{}
+	}
+
+	return airing, true
+}
+
+// AiringSchedule returns the airing titles on list with one of the given
+// statuses, soonest next episode first
+func AiringSchedule(list *UserList, now time.Time, statuses ...string) []Airing {
+	var schedule []Airing
+	for _, status := range statuses {
+		for _, entry := range list.WithStatus(status) {
+			airing, ok := EstimateAiring(entry.Node, now)
+			if !ok || airing.Next.IsZero() {
+				continue
+			}
+			airing.Entry = entry
+			schedule = append(schedule, airing)
+		}
+	}
+
+	sort.SliceStable(schedule, func(i, j int) bool {
+		return schedule[i].Next.Before(schedule[j].Next)
+	})
+
+	return schedule
+}
+
+// WeekSchedule returns the titles of AiringSchedule with an episode airing
+// within a week of now
+func WeekSchedule(list *UserList, now time.Time, statuses ...string) []Airing {
+	schedule := AiringSchedule(list, now, statuses...)
+	for i, airing := range schedule {
+		if !airing.Next.Before(now.Add(week)) {
+			return schedule[:i]
+		}
+	}
+	return schedule
+}
@@ -0,0 +1,173 @@
+package lib
+
+import (
+	"testing"
+	"time"
+)
+
+// saturday is the broadcast slot of the titles below, and the first
+// episode airs at the first slot on or after the start date
+var saturday = &Broadcast{DayOfTheWeek: "saturday", StartTime: "01:30"}
+
+var firstEpisode = time.Date(2024, 1, 6, 1, 30, 0, 0, jst)
+
+func airingTitle(status, startDate string, episodes int) Title {
+	return Title{ID: 1, Status: status, StartDate: startDate, NumEpisodes: episodes, Broadcast: saturday}
+}
+
+func TestEstimateAiring(t *testing.T) {
+	tests := []struct {
+		name  string
+		title Title
+		now   time.Time
+		want  Airing
+		ok    bool
+	}{
+		{
+			name:  "before the first episode",
+			title: airingTitle("not_yet_aired", "2024-01-06", 12),
+			now:   time.Date(2024, 1, 1, 0, 0, 0, 0, jst),
+			want:  Airing{Next: firstEpisode, First: firstEpisode},
+			ok:    true,
+		},
+		{
+			name:  "start date before the broadcast day",
+			title: airingTitle("not_yet_aired", "2024-01-04", 12),
+			now:   time.Date(2024, 1, 1, 0, 0, 0, 0, jst),
+			want:  Airing{Next: firstEpisode, First: firstEpisode},
+			ok:    true,
+		},
+		{
+			name:  "first episode airing",
+			title: airingTitle("currently_airing", "2024-01-06", 12),
+			now:   firstEpisode,
+			want:  Airing{Aired: 1, Next: firstEpisode.AddDate(0, 0, 7), First: firstEpisode},
+			ok:    true,
+		},
+		{
+			name:  "mid-run",
+			title: airingTitle("currently_airing", "2024-01-06", 12),
+			now:   firstEpisode.AddDate(0, 0, 14).Add(time.Hour),
+			want:  Airing{Aired: 3, Next: firstEpisode.AddDate(0, 0, 21), First: firstEpisode},
+			ok:    true,
+		},
+		{
+			name:  "capped at the episode count",
+			title: airingTitle("currently_airing", "2024-01-06", 2),
+			now:   firstEpisode.AddDate(0, 0, 21),
+			want:  Airing{Aired: 2, First: firstEpisode},
+			ok:    true,
+		},
+		{
+			name:  "unknown episode count",
+			title: airingTitle("currently_airing", "2024-01-06", 0),
+			now:   firstEpisode.AddDate(0, 0, 70),
+			want:  Airing{Aired: 11, Next: firstEpisode.AddDate(0, 0, 77), First: firstEpisode},
+			ok:    true,
+		},
+		{
+			name:  "without a start date",
+			title: airingTitle("currently_airing", "2024", 12),
+			now:   time.Date(2024, 1, 10, 12, 0, 0, 0, jst),
+			want:  Airing{Next: time.Date(2024, 1, 13, 1, 30, 0, 0, jst)},
+			ok:    true,
+		},
+		{
+			name:  "finished",
+			title: airingTitle("finished_airing", "2024-01-06", 12),
+			now:   firstEpisode,
+			want:  Airing{Aired: 12},
+			ok:    true,
+		},
+		{
+			name:  "unknown status",
+			title: airingTitle("", "2024-01-06", 12),
+			now:   firstEpisode,
+		},
+		{
+			name:  "no broadcast",
+			title: Title{Status: "currently_airing", StartDate: "2024-01-06"},
+			now:   firstEpisode,
+		},
+		{
+			name:  "unknown broadcast day",
+			title: Title{Status: "currently_airing", Broadcast: &Broadcast{DayOfTheWeek: "other", StartTime: "01:30"}},
+			now:   firstEpisode,
+		},
+		{
+			name:  "bad broadcast time",
+			title: Title{Status: "currently_airing", Broadcast: &Broadcast{DayOfTheWeek: "saturday", StartTime: "late"}},
+			now:   firstEpisode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			airing, ok := EstimateAiring(tt.title, tt.now)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if airing.Aired != tt.want.Aired || !airing.Next.Equal(tt.want.Next) || !airing.First.Equal(tt.want.First) {
+				t.Errorf("airing = %d aired, next %v, first %v; want %d aired, next %v, first %v",
+					airing.Aired, airing.Next, airing.First, tt.want.Aired, tt.want.Next, tt.want.First)
+			}
+		})
+	}
+}
+
+func TestAiringBehind(t *testing.T) {
+	tests := []struct {
+		name    string
+		airing  Airing
+		watched int
+		want    int
+		known   bool
+	}{
+		{"behind", Airing{Aired: 5, First: firstEpisode}, 3, 2, true},
+		{"caught up", Airing{Aired: 5, First: firstEpisode}, 5, 0, true},
+		{"watched more than aired", Airing{Aired: 5, First: firstEpisode}, 7, 0, true},
+		{"finished without a start date", Airing{Aired: 12}, 4, 8, true},
+		{"no start date", Airing{}, 4, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.airing.Entry.ListStatus.NumEpisodesWatched = tt.watched
+			behind, known := tt.airing.Behind()
+			if behind != tt.want || known != tt.known {
+				t.Errorf("Behind() = %d, %v, want %d, %v", behind, known, tt.want, tt.known)
+			}
+		})
+	}
+}
+
+func TestWeekSchedule(t *testing.T) {
+	now := firstEpisode.AddDate(0, 0, 2)
+	entry := func(id int, status, startDate string) ListEntry {
+		title := airingTitle("currently_airing", startDate, 12)
+		title.ID = id
+		return ListEntry{Node: title, ListStatus: ListStatus{Status: status}}
+	}
+	list := &UserList{MediaType: "anime", Entries: []ListEntry{
+		// Next episode in five days
+		entry(1, "watching", "2024-01-06"),
+		// Premieres in twelve days
+		entry(2, "watching", "2024-01-20"),
+		// Airs this week, but isn't being watched
+		entry(3, "dropped", "2024-01-06"),
+		// Premieres in five days
+		entry(4, "plan_to_watch", "2024-01-13"),
+	}}
+	list.Entries[3].Node.Status = "not_yet_aired"
+
+	var ids []int
+	for _, airing := range WeekSchedule(list, now, "watching", "plan_to_watch") {
+		ids = append(ids, airing.Entry.Node.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 4 {
+		t.Errorf("scheduled titles = %v, want [1 4]", ids)
+	}
+}
@@ -0,0 +1,53 @@
+package lib
+
+import (
+	"fmt"
+	"time"
+)
+
+// Seasons lists the anime seasons in calendar order
+var Seasons = []string{"winter", "spring", "summer", "fall"}
+
+// CurrentSeason returns the anime season t falls in
+func CurrentSeason(t time.Time) (int, string) {
+	return t.Year(), Seasons[(int(t.Month())-1)/3]
+}
+
+// ShiftSeason returns the season delta seasons before or after the given one
+func ShiftSeason(year int, season string, delta int) (int, string) {
+	index := 0
+	for i, s := range Seasons {
+		if s == season {
+			index = i
+		}
+	}
+
+	index += delta
+	year += index / len(Seasons)
+	index %= len(Seasons)
+	if index < 0 {
+		index += len(Seasons)
+		year--
+	}
+
+	return year, Seasons[index]
+}
+
+// GetSeasonalAnime fetches the anime airing in a season, sorted by
+// "anime_score" or "anime_num_list_users"
+func GetSeasonalAnime(year int, season, sort string) ([]Title, error) {
+	path := fmt.Sprintf("/anime/season/%d/%s?sort=%s&limit=500&fields=%s&nsfw=true",
+		year, season, sort, titleFields("anime"))
+
+	nodes, _, err := fetchMALPage[titleNode](path)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make([]Title, len(nodes))
+	for i, node := range nodes {
+		titles[i] = node.Node
+	}
+
+	return titles, nil
+}
@@ -0,0 +1,229 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Count is a chart bucket
+type Count struct {
+	Label string
+	Value float64
+}
+
+// Completion is how many titles of a media type the user finished out of
+// those they started
+type Completion struct {
+	MediaType string
+	Completed int
+	Started   int
+}
+
+// Rate returns the completed share of started titles, between 0 and 1
+func (c Completion) Rate() float64 {
+	if c.Started == 0 {
+		return 0
+	}
+	return float64(c.Completed) / float64(c.Started)
+}
+
+// ScoreDifference compares the user's score of a title with its MAL mean
+type ScoreDifference struct {
+	Title string
+	Score int
+	Mean  float64
+}
+
+// Difference returns how far the user's score is above the MAL mean
+func (d ScoreDifference) Difference() float64 {
+	return float64(d.Score) - d.Mean
+}
+
+// ListStats is the analytics computed from a local list. Activity is
+// measured in hours watched for anime and chapters read for manga, and
+// attributed to when the user was busy with the title, see activityDate.
+type ListStats struct {
+	MediaType string
+	// Scores holds the number of titles scored 1 to 10
+	Scores           [10]int
+	Genres           []Count
+	Studios          []Count
+	ActivityByYear   []Count
+	ActivityBySeason []Count
+	Completion       []Completion
+	// MeanScore and MALMean average the user's scores and the MAL means of
+	// the same scored titles
+	MeanScore   float64
+	MALMean     float64
+	Differences []ScoreDifference
+}
+
+// ComputeStats works out the analytics of a list. Titles the user only
+// plans to watch or read are left out of everything but the completion
+// rates' media types.
+func ComputeStats(list *UserList) ListStats {
+	stats := ListStats{MediaType: list.MediaType}
+	genres := map[string]float64{}
+	studios := map[string]float64{}
+	years := map[string]float64{}
+	seasons := map[string]float64{}
+	completion := map[string]*Completion{}
+	var scoreSum, meanSum float64
+
+	for _, entry := range list.Entries {
+		title, status := entry.Node, entry.ListStatus
+		if status.Status == "plan_to_watch" || status.Status == "plan_to_read" {
+			continue
+		}
+
+		if status.Score > 0 {
+			stats.Scores[status.Score-1]++
+			if title.Mean > 0 {
+				scoreSum += float64(status.Score)
+				meanSum += title.Mean
+				stats.Differences = append(stats.Differences, ScoreDifference{Title: title.Title, Score: status.Score, Mean: title.Mean})
+			}
+		}
+
+		for _, genre := range title.Genres {
+			genres[genre.Name]++
+		}
+		for _, studio := range title.Studios {
+			studios[studio.Name]++
+		}
+
+		mediaType := title.MediaType
+		if mediaType == "" {
+			mediaType = "unknown"
+		}
+		if completion[mediaType] == nil {
+			completion[mediaType] = &Completion{MediaType: mediaType}
+		}
+		completion[mediaType].Started++
+		if status.Status == "completed" {
+			completion[mediaType].Completed++
+		}
+
+		activity := entryActivity(entry)
+		if activity == 0 {
+			continue
+		}
+		if year, season, ok := activityDate(entry); ok {
+			years[strconv.Itoa(year)] += activity
+			if season != "" {
+				seasons[fmt.Sprintf("%d %s", year, season)] += activity
+			}
+		}
+	}
+
+	if len(stats.Differences) > 0 {
+		stats.MeanScore = scoreSum / float64(len(stats.Differences))
+		stats.MALMean = meanSum / float64(len(stats.Differences))
+	}
+	sort.SliceStable(stats.Differences, func(i, j int) bool {
+		return stats.Differences[i].Difference() > stats.Differences[j].Difference()
+	})
+
+	stats.Genres = sortedCounts(genres)
+	stats.Studios = sortedCounts(studios)
+	stats.ActivityByYear = chronologicalCounts(years)
+	stats.ActivityBySeason = chronologicalCounts(seasons)
+
+	for _, c := range completion {
+		stats.Completion = append(stats.Completion, *c)
+	}
+	sort.Slice(stats.Completion, func(i, j int) bool {
+		return stats.Completion[i].Started > stats.Completion[j].Started
+	})
+
+	return stats
+}
+
+// entryActivity returns the hours spent watching an anime, including
+// rewatches, or the chapters read of a manga
+func entryActivity(entry ListEntry) float64 {
+	title, status := entry.Node, entry.ListStatus
+	if title.NumChapters > 0 || status.NumChaptersRead > 0 {
+		return float64(status.NumChaptersRead + status.NumTimesReread*title.NumChapters)
+	}
+	episodes := status.NumEpisodesWatched + status.NumTimesRewatched*title.NumEpisodes
+	return float64(episodes*title.AverageEpisodeDuration) / 3600
+}
+
+// activityDate returns the year and season the user spent on an entry: when
+// they finished it, started it or last updated it. Only entries without any
+// of those fall back to when the title aired. The season is empty when only
+// the year is known.
+func activityDate(entry ListEntry) (int, string, bool) {
+	status, title := entry.ListStatus, entry.Node
+	for _, date := range []string{status.FinishDate, status.StartDate, status.UpdatedAt} {
+		if year, season, ok := dateSeason(date); ok {
+			return year, season, true
+		}
+	}
+
+	if title.StartSeason != nil {
+		return title.StartSeason.Year, title.StartSeason.Season, true
+	}
+	return dateSeason(title.StartDate)
+}
+
+// dateSeason parses the year and season of a MAL date, which may be just
+// "2006" or "2006-01" as well as a full date or timestamp
+func dateSeason(date string) (int, string, bool) {
+	if len(date) < 4 {
+		return 0, "", false
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0, "", false
+	}
+	if len(date) < 7 {
+		return year, "", true
+	}
+	month, err := strconv.Atoi(date[5:7])
+	if err != nil || month < 1 || month > 12 {
+		return year, "", true
+	}
+	return year, Seasons[(month-1)/3], true
+}
+
+// sortedCounts returns the buckets largest first
+func sortedCounts(counts map[string]float64) []Count {
+	result := make([]Count, 0, len(counts))
+	for label, value := range counts {
+		result = append(result, Count{Label: label, Value: value})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Value != result[j].Value {
+			return result[i].Value > result[j].Value
+		}
+		return result[i].Label < result[j].Label
+	})
+	return result
+}
+
+// chronologicalCounts returns buckets labelled "year" or "year season" in
+// calendar order
+func chronologicalCounts(counts map[string]float64) []Count {
+	result := sortedCounts(counts)
+	seasonIndex := map[string]int{}
+	for i, season := range Seasons {
+		seasonIndex[season] = i
+	}
+	key := func(label string) (string, int) {
+		var year, season string
+		fmt.Sscan(label, &year, &season)
+		return year, seasonIndex[season]
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		yearI, seasonI := key(result[i].Label)
+		yearJ, seasonJ := key(result[j].Label)
+		if yearI != yearJ {
+			return yearI < yearJ
+		}
+		return seasonI < seasonJ
+	})
+	return result
+}
@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+)
+
+// suggestionSources is how many of the user's favorite titles suggestions
+// are gathered from
+const suggestionSources = 10
+
+// TitleRecommendation is a title MAL users recommend to fans of another
+type TitleRecommendation struct {
+	Entry JikanEntry `json:"entry"`
+	URL   string     `json:"url"`
+	Votes int        `json:"votes"`
+}
+
+// Suggestion is a title recommended for several of the user's favorites
+type Suggestion struct {
+	Entry JikanEntry
+	// Votes is the sum of the recommendation votes over all sources
+	Votes int
+	// Sources are the titles on the user's list it was recommended for
+	Sources []string
+}
+
+// GetTitleRecommendations fetches what MAL users recommend to fans of a title
+func GetTitleRecommendations(mediaType string, malID int) ([]TitleRecommendation, error) {
+	return fetchJikanList[TitleRecommendation](fmt.Sprintf("/%s/%d/recommendations", mediaType, malID))
+}
+
+// SuggestTitles gathers the recommendations for the user's highest scored
+// completed titles, leaving out titles already on their list, including
+// those only added by updates still waiting in the journal. Titles
+// recommended for more of them come first, then those with more votes.
+// When a request fails, the suggestions gathered so far are returned along
+// with the error.
+func SuggestTitles(list *UserList) ([]Suggestion, error) {
+	var favorites []ListEntry
+	for _, entry := range list.WithStatus("completed") {
+		if entry.ListStatus.Score > 0 {
+			favorites = append(favorites, entry)
+		}
+	}
+	sort.SliceStable(favorites, func(i, j int) bool {
+		a, b := favorites[i].ListStatus, favorites[j].ListStatus
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.UpdatedAt > b.UpdatedAt
+	})
+	favorites = favorites[:min(suggestionSources, len(favorites))]
+
+	pending, err := PendingUpdates()
+	if err != nil {
+		return nil, err
+	}
+	queued := make(map[int]bool)
+	for _, p := range pending {
+		if p.Update.MediaType == list.MediaType {
+			queued[p.Update.MALID] = true
+		}
+	}
+
+	suggestions := map[int]*Suggestion{}
+	for _, favorite := range favorites {
+		var recommendations []TitleRecommendation
+		recommendations, err = GetTitleRecommendations(list.MediaType, favorite.Node.ID)
+		if err != nil {
+			break
+		}
+
+		for _, rec := range recommendations {
+			if list.Find(rec.Entry.MALId) != nil || queued[rec.Entry.MALId] {
+				continue
+			}
+			suggestion := suggestions[rec.Entry.MALId]
+			if suggestion == nil {
+				suggestion = &Suggestion{Entry: rec.Entry}
+				suggestions[rec.Entry.MALId] = suggestion
+			}
+			suggestion.Votes += rec.Votes
+			suggestion.Sources = append(suggestion.Sources, favorite.Node.Title)
+		}
+	}
+
+	result := make([]Suggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		result = append(result, *suggestion)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if len(a.Sources) != len(b.Sources) {
+			return len(a.Sources) > len(b.Sources)
+		}
+		if a.Votes != b.Votes {
+			return a.Votes > b.Votes
+		}
+		return a.Entry.Title < b.Entry.Title
+	})
+
+	return result, err
+}
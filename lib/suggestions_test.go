@@ -0,0 +1,56 @@
+package lib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSuggestTitles(t *testing.T) {
+	stubMAL(t)
+
+	// Both favorites are recommended Monster, and Berserk, which the user
+	// added while offline
+	var requests int
+	httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		page := jikanPage[TitleRecommendation]{Data: []TitleRecommendation{
+			{Entry: JikanEntry{MALId: 19, Title: "Monster"}, Votes: 10},
+			{Entry: JikanEntry{MALId: 33, Title: "Berserk"}, Votes: 20},
+		}}
+		recorder := httptest.NewRecorder()
+		json.NewEncoder(recorder).Encode(page)
+		return recorder.Result(), nil
+	})
+	queue(t, ListUpdate{MediaType: "anime", MALID: 33, Fields: map[string]string{"status": "plan_to_watch"}})
+
+	list := &UserList{MediaType: "anime", Entries: []ListEntry{
+		{Node: Title{ID: 1, Title: "Cowboy Bebop"}, ListStatus: ListStatus{Status: "completed", Score: 10}},
+		{Node: Title{ID: 5114, Title: "Fullmetal Alchemist: Brotherhood"}, ListStatus: ListStatus{Status: "completed", Score: 9}},
+	}}
+
+	suggestions, err := SuggestTitles(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Entry.MALId != 19 || suggestions[0].Votes != 20 || len(suggestions[0].Sources) != 2 {
+		t.Errorf("suggestions = %+v, want Monster from both favorites", suggestions)
+	}
+	if requests != 2 {
+		t.Errorf("%d requests sent, want 2", requests)
+	}
+
+	// Cached recommendations are served without waiting on the rate limit
+	start := time.Now()
+	if _, err := SuggestTitles(list); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("%d requests sent, want the cached responses used", requests)
+	}
+	if elapsed := time.Since(start); elapsed >= jikanRequestInterval {
+		t.Errorf("suggesting from the cache took %v", elapsed)
+	}
+}
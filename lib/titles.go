@@ -0,0 +1,121 @@
+package lib
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Picture holds the cover URLs MAL returns as main_picture
+type Picture struct {
+	Medium string `json:"medium"`
+	Large  string `json:"large"`
+}
+
+type Genre struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Studio struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Broadcast is when new episodes air each week, in Japan Standard Time
+type Broadcast struct {
+	DayOfTheWeek string `json:"day_of_the_week"`
+	StartTime    string `json:"start_time,omitempty"`
+}
+
+type Season struct {
+	Year   int    `json:"year"`
+	Season string `json:"season"`
+}
+
+// Title is an anime or manga as returned by the MAL API. Anime and manga
+// share it, leaving the other's fields empty.
+type Title struct {
+	ID                     int         `json:"id"`
+	Title                  string      `json:"title"`
+	MainPicture            Picture     `json:"main_picture"`
+	MediaType              string      `json:"media_type,omitempty"`
+	Status                 string      `json:"status,omitempty"`
+	Mean                   float64     `json:"mean,omitempty"`
+	NumListUsers           int         `json:"num_list_users,omitempty"`
+	StartDate              string      `json:"start_date,omitempty"`
+	EndDate                string      `json:"end_date,omitempty"`
+	Genres                 []Genre     `json:"genres,omitempty"`
+	NumEpisodes            int         `json:"num_episodes,omitempty"`
+	AverageEpisodeDuration int         `json:"average_episode_duration,omitempty"`
+	StartSeason            *Season     `json:"start_season,omitempty"`
+	Studios                []Studio    `json:"studios,omitempty"`
+	Broadcast              *Broadcast  `json:"broadcast,omitempty"`
+	NumChapters            int         `json:"num_chapters,omitempty"`
+	NumVolumes             int         `json:"num_volumes,omitempty"`
+	Synopsis               string      `json:"synopsis,omitempty"`
+	Rank                   int         `json:"rank,omitempty"`
+	Popularity             int         `json:"popularity,omitempty"`
+	MyListStatus           *ListStatus `json:"my_list_status,omitempty"`
+}
+
+// titleNode wraps a title in MAL's paginated responses
+type titleNode struct {
+	Node Title `json:"node"`
+}
+
+// titleFields returns the fields requested for titles shown in lists. MAL
+// rejects anime fields on manga endpoints and vice versa.
+func titleFields(mediaType string) string {
+	fields := "id,title,main_picture,media_type,status,mean,num_list_users,start_date,end_date,genres"
+	if mediaType == "manga" {
+		return fields + ",num_chapters,num_volumes"
+	}
+	return fields + ",num_episodes,average_episode_duration,start_season,studios,broadcast"
+}
+
+// Total returns the number of episodes or chapters, or 0 when unknown
+func (t Title) Total() int {
+	if t.NumEpisodes > 0 {
+		return t.NumEpisodes
+	}
+	return t.NumChapters
+}
+
+// titleDetailFields returns the fields requested when showing a single title
+func titleDetailFields(mediaType string) string {
+	return titleFields(mediaType) + ",synopsis,rank,popularity,my_list_status"
+}
+
+// SearchTitles searches MAL for anime or manga matching query
+func SearchTitles(mediaType, query string, limit int) ([]Title, error) {
+	path := fmt.Sprintf("/%s?q=%s&limit=%d&fields=%s&nsfw=true",
+		mediaType, url.QueryEscape(query), limit, titleFields(mediaType))
+
+	nodes, _, err := fetchMALPage[titleNode](path)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make([]Title, len(nodes))
+	for i, node := range nodes {
+		titles[i] = node.Node
+	}
+
+	return titles, nil
+}
+
+// GetTitle fetches the details of a single anime or manga, including the
+// user's list status
+func GetTitle(mediaType string, malID int) (*Title, error) {
+	req, err := newMALRequest("GET", fmt.Sprintf("/%s/%d?fields=%s", mediaType, malID, titleDetailFields(mediaType)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var title Title
+	if err := doJSON(req, &title); err != nil {
+		return nil, err
+	}
+
+	return &title, nil
+}
@@ -1,45 +1,179 @@
 package lib
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"yato/config"
 )
 
+// errUnknownUser is returned when data of the logged in user is needed
+// before their MAL ID was ever fetched
+var errUnknownUser = errors.New("the logged in user isn't known yet, connect to MyAnimeList once")
+
 type MALUser struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Birthday string `json:"birthday"`
-	Location string `json:"location"`
-	JoinedAt string `json:"joined_at"`
-	Picture  string `json:"picture"`
+	ID              int             `json:"id"`
+	Name            string          `json:"name"`
+	Birthday        string          `json:"birthday"`
+	Location        string          `json:"location"`
+	JoinedAt        string          `json:"joined_at"`
+	Picture         string          `json:"picture"`
+	AnimeStatistics AnimeStatistics `json:"anime_statistics"`
+}
+
+// AnimeStatistics is the summary of the user's anime list kept by MAL
+type AnimeStatistics struct {
+	NumItemsWatching    int     `json:"num_items_watching"`
+	NumItemsCompleted   int     `json:"num_items_completed"`
+	NumItemsOnHold      int     `json:"num_items_on_hold"`
+	NumItemsDropped     int     `json:"num_items_dropped"`
+	NumItemsPlanToWatch int     `json:"num_items_plan_to_watch"`
+	NumItems            int     `json:"num_items"`
+	NumDaysWatched      float64 `json:"num_days_watched"`
+	NumDaysWatching     float64 `json:"num_days_watching"`
+	NumDaysCompleted    float64 `json:"num_days_completed"`
+	NumDaysOnHold       float64 `json:"num_days_on_hold"`
+	NumDaysDropped      float64 `json:"num_days_dropped"`
+	NumDays             float64 `json:"num_days"`
+	NumEpisodes         int     `json:"num_episodes"`
+	NumTimesRewatched   int     `json:"num_times_rewatched"`
+	MeanScore           float64 `json:"mean_score"`
 }
 
+// CurrentUser returns the logged in user, from the response cache while it
+// is fresh. Callers record the ID with RememberUser.
 func CurrentUser() (*MALUser, error) {
+	return currentUser(fetchJSON)
+}
+
+// RefreshCurrentUser is CurrentUser bypassing the response cache, for
+// showing statistics that change as the list is updated
+func RefreshCurrentUser() (*MALUser, error) {
+	return currentUser(revalidateJSON)
+}
+
+func currentUser(fetch func(*http.Request, interface{}) error) (*MALUser, error) {
 	var user MALUser
 
-	req, err := http.NewRequest("GET", "https://api.myanimelist.net/v2/users/@me", nil)
+	req, err := newMALRequest("GET", "/users/@me?fields=anime_statistics", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", config.GetConfig().MyAnimeList.AccessToken))
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	if err := fetch(req, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// RememberUser records the logged in user in the config. Their list mirror
+// and pending updates are stored under their ID, so switching accounts never
+// mixes them up. It saves the config, so only call it from the goroutine
+// owning it.
+func RememberUser(id int) error {
+	mal := &config.GetConfig().MyAnimeList
+	if mal.UserID == id {
+		return nil
+	}
+
+	previous := mal.UserID
+	mal.UserID = id
+	if err := config.SaveConfig(); err != nil {
+		return err
+	}
+
+	if previous == 0 {
+		return adoptSharedData()
+	}
+	return nil
+}
+
+// userDataDir returns the directory holding the list mirror and pending
+// updates of the logged in user
+func userDataDir() (string, error) {
+	id := config.GetConfig().MyAnimeList.UserID
+	if id == 0 {
+		return "", errUnknownUser
+	}
+
+	dataDir, err := config.DataDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return "", err
 	}
+	return filepath.Join(dataDir, "users", strconv.Itoa(id)), nil
+}
 
-	defer resp.Body.Close()
+// sharedDataFiles are the names the list mirror and pending updates had
+// before they were stored per user
+var sharedDataFiles = []string{"lists", "pending.json"}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+// adoptSharedData moves data stored before it was kept per user to the
+// logged in user, who is the one it was recorded for
+func adoptSharedData() error {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return err
 	}
+	userDir, err := userDataDir()
+	if err != nil {
+		return err
+	}
+
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	listMu.Lock()
+	defer listMu.Unlock()
 
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	for _, name := range sharedDataFiles {
+		from, to := filepath.Join(dataDir, name), filepath.Join(userDir, name)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if _, err := os.Stat(to); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(userDir, 0755); err != nil {
+			return fmt.Errorf("failed to create user data dir: %w", err)
+		}
+		if err := os.Rename(from, to); err != nil {
+			return fmt.Errorf("failed to move %s: %w", name, err)
+		}
 	}
 
-	return &user, nil
+	return nil
+}
+
+// ForgetUser deletes the list mirror, the pending updates and the cached API
+// responses of the logged in user, so none of them reach the next account
+func ForgetUser() error {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	listMu.Lock()
+	defer listMu.Unlock()
+
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(sharedDataFiles)+1)
+	for _, name := range sharedDataFiles {
+		paths = append(paths, filepath.Join(dataDir, name))
+	}
+	if userDir, err := userDataDir(); err == nil {
+		paths = append(paths, userDir)
+	}
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove user data: %w", err)
+		}
+	}
+
+	return clearResponses()
 }
@@ -0,0 +1,178 @@
+package lib
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// WrappedFormats lists the formats accepted by WriteWrapped
+var WrappedFormats = []string{"markdown", "html"}
+
+// wrappedTopCount is how many titles and genres each section lists
+const wrappedTopCount = 5
+
+// Wrapped is the year in review of the user's lists
+type Wrapped struct {
+	Year            int
+	Completed       []ListEntry
+	CompletedAnime  int
+	CompletedManga  int
+	HoursWatched    float64
+	EpisodesWatched int
+	ChaptersRead    int
+	TopGenres       []Count
+	TopRated        []ListEntry
+	Disagreements   []ScoreDifference
+}
+
+// ComputeWrapped reviews the titles completed in year. A title counts for
+// the year of its finish date, or of its last update when it has none.
+// Watch time only includes completed titles, as MAL doesn't record when
+// the episodes of other titles were watched.
+func ComputeWrapped(year int, lists ...*UserList) Wrapped {
+	wrapped := Wrapped{Year: year}
+	genres := map[string]float64{}
+	prefix := fmt.Sprint(year)
+
+	for _, list := range lists {
+		for _, entry := range list.Entries {
+			status := entry.ListStatus
+			finished := status.FinishDate
+			if finished == "" {
+				finished = status.UpdatedAt
+			}
+			if status.Status != "completed" || !strings.HasPrefix(finished, prefix) {
+				continue
+			}
+
+			wrapped.Completed = append(wrapped.Completed, entry)
+			if list.MediaType == "manga" {
+				wrapped.CompletedManga++
+				wrapped.ChaptersRead += status.NumChaptersRead
+			} else {
+				wrapped.CompletedAnime++
+				wrapped.EpisodesWatched += status.NumEpisodesWatched
+				wrapped.HoursWatched += float64(status.NumEpisodesWatched*entry.Node.AverageEpisodeDuration) / 3600
+			}
+
+			for _, genre := range entry.Node.Genres {
+				genres[genre.Name]++
+			}
+			if status.Score > 0 && entry.Node.Mean > 0 {
+				wrapped.Disagreements = append(wrapped.Disagreements, ScoreDifference{
+					Title: entry.Node.Title,
+					Score: status.Score,
+					Mean:  entry.Node.Mean,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(wrapped.Completed, func(i, j int) bool {
+		return wrapped.Completed[i].Node.Title < wrapped.Completed[j].Node.Title
+	})
+
+	wrapped.TopGenres = sortedCounts(genres)
+	wrapped.TopGenres = wrapped.TopGenres[:min(wrappedTopCount, len(wrapped.TopGenres))]
+
+	for _, entry := range wrapped.Completed {
+		if entry.ListStatus.Score > 0 {
+			wrapped.TopRated = append(wrapped.TopRated, entry)
+		}
+	}
+	sort.SliceStable(wrapped.TopRated, func(i, j int) bool {
+		return wrapped.TopRated[i].ListStatus.Score > wrapped.TopRated[j].ListStatus.Score
+	})
+	wrapped.TopRated = wrapped.TopRated[:min(wrappedTopCount, len(wrapped.TopRated))]
+
+	sort.SliceStable(wrapped.Disagreements, func(i, j int) bool {
+		return math.Abs(wrapped.Disagreements[i].Difference()) > math.Abs(wrapped.Disagreements[j].Difference())
+	})
+	wrapped.Disagreements = wrapped.Disagreements[:min(wrappedTopCount, len(wrapped.Disagreements))]
+
+	return wrapped
+}
+
+const wrappedMarkdown = `# {{.Year}} in review
+
+- **{{.CompletedAnime}}** anime completed, **{{.EpisodesWatched}}** episodes, **{{printf "%.0f" .HoursWatched}}** hours
+- **{{.CompletedManga}}** manga completed, **{{.ChaptersRead}}** chapters
+{{if .TopGenres}}
+## Top genres
+{{range .TopGenres}}
+- {{.Label}} ({{.Value}})
+{{- end}}
+{{end}}{{if .TopRated}}
+## Highest rated
+{{range .TopRated}}
+- {{.ListStatus.Score}}/10 {{.Node.Title}}
+{{- end}}
+{{end}}{{if .Disagreements}}
+## Biggest disagreements with MAL
+{{range .Disagreements}}
+- {{printf "%+.2f" .Difference}} {{.Title}} (you {{.Score}}, MAL {{printf "%.2f" .Mean}})
+{{- end}}
+{{end}}{{if .Completed}}
+## Completed
+{{range .Completed}}
+- {{.Node.Title}}{{if .ListStatus.Score}} ({{.ListStatus.Score}}/10){{end}}
+{{- end}}
+{{end}}`
+
+const wrappedHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Year}} in review</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; line-height: 1.5; }
+h1, h2 { color: #2F51A2; }
+</style>
+</head>
+<body>
+<h1>{{.Year}} in review</h1>
+<ul>
+<li><strong>{{.CompletedAnime}}</strong> anime completed, <strong>{{.EpisodesWatched}}</strong> episodes, <strong>{{printf "%.0f" .HoursWatched}}</strong> hours</li>
+<li><strong>{{.CompletedManga}}</strong> manga completed, <strong>{{.ChaptersRead}}</strong> chapters</li>
+</ul>
+{{- if .TopGenres}}
+<h2>Top genres</h2>
+<ol>{{range .TopGenres}}<li>{{.Label}} ({{.Value}})</li>{{end}}</ol>
+{{- end}}
+{{- if .TopRated}}
+<h2>Highest rated</h2>
+<ol>{{range .TopRated}}<li>{{.ListStatus.Score}}/10 {{.Node.Title}}</li>{{end}}</ol>
+{{- end}}
+{{- if .Disagreements}}
+<h2>Biggest disagreements with MAL</h2>
+<ul>{{range .Disagreements}}<li>{{printf "%+.2f" .Difference}} {{.Title}} (you {{.Score}}, MAL {{printf "%.2f" .Mean}})</li>{{end}}</ul>
+{{- end}}
+{{- if .Completed}}
+<h2>Completed</h2>
+<ul>{{range .Completed}}<li>{{.Node.Title}}{{if .ListStatus.Score}} ({{.ListStatus.Score}}/10){{end}}</li>{{end}}</ul>
+{{- end}}
+</body>
+</html>
+`
+
+var (
+	wrappedMarkdownTemplate = texttemplate.Must(texttemplate.New("wrapped").Parse(wrappedMarkdown))
+	wrappedHTMLTemplate     = htmltemplate.Must(htmltemplate.New("wrapped").Parse(wrappedHTML))
+)
+
+// WriteWrapped renders the year in review to w as Markdown or HTML
+func WriteWrapped(w io.Writer, format string, wrapped Wrapped) error {
+	switch format {
+	case "markdown":
+		return wrappedMarkdownTemplate.Execute(w, wrapped)
+	case "html":
+		return wrappedHTMLTemplate.Execute(w, wrapped)
+	default:
+		return fmt.Errorf("unknown wrapped format %q", format)
+	}
+}
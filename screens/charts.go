@@ -0,0 +1,97 @@
+package screens
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"yato/config"
+	"yato/lib"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// eighths are the block characters for partially filled cells, from one
+// eighth up to a full block
+var (
+	horizontalEighths = []rune("▏▎▍▌▋▊▉█")
+	verticalEighths   = []rune("▁▂▃▄▅▆▇█")
+)
+
+var chartStyle = lipgloss.NewStyle().Foreground(config.Colors.Primary)
+
+// bar draws a horizontal bar of value/maximum of width cells, with eighth
+// cell precision
+func bar(value, maximum float64, width int) string {
+	if maximum <= 0 || value <= 0 {
+		return ""
+	}
+	eighths := int(math.Round(value / maximum * float64(width*8)))
+	full, rest := eighths/8, eighths%8
+
+	bar := strings.Repeat("█", full)
+	if rest > 0 {
+		bar += string(horizontalEighths[rest-1])
+	}
+	return chartStyle.Render(bar)
+}
+
+// barChart draws one labelled horizontal bar per count, followed by the value
+// rendered by format. Bars are scaled to maximum, or to the largest count
+// when maximum is 0.
+func barChart(counts []lib.Count, maximum float64, width int, format func(float64) string) string {
+	labelWidth, largest := 0, 0.0
+	for _, count := range counts {
+		labelWidth = max(labelWidth, len([]rune(count.Label)))
+		largest = max(largest, count.Value)
+	}
+	if maximum == 0 {
+		maximum = largest
+	}
+	labelWidth = min(labelWidth, 24)
+	barWidth := max(10, width-labelWidth-12)
+
+	var b strings.Builder
+	for _, count := range counts {
+		rendered := bar(count.Value, maximum, barWidth)
+		padding := strings.Repeat(" ", barWidth-lipgloss.Width(rendered))
+		fmt.Fprintf(&b, "%-*s %s%s %s\n", labelWidth, truncate(count.Label, labelWidth), rendered, padding, format(count.Value))
+	}
+	return b.String()
+}
+
+// histogram draws vertical bars of height rows, one per value, with the
+// labels underneath. Each column is columnWidth cells wide.
+func histogram(values []int, labels []string, height, columnWidth int) string {
+	maximum := 0
+	for _, value := range values {
+		maximum = max(maximum, value)
+	}
+
+	var b strings.Builder
+	for row := height - 1; row >= 0; row-- {
+		for _, value := range values {
+			cell := " "
+			if maximum > 0 {
+				eighths := int(math.Round(float64(value) / float64(maximum) * float64(height*8)))
+				switch filled := eighths - row*8; {
+				case filled >= 8:
+					cell = "█"
+				case filled > 0:
+					cell = string(verticalEighths[filled-1])
+				}
+			}
+			b.WriteString(chartStyle.Render(strings.Repeat(cell, columnWidth-1)) + " ")
+		}
+		b.WriteString("\n")
+	}
+	for _, label := range labels {
+		fmt.Fprintf(&b, "%-*s", columnWidth, label)
+	}
+	b.WriteString("\n")
+	for _, value := range values {
+		fmt.Fprintf(&b, "%-*d", columnWidth, value)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
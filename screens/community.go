@@ -0,0 +1,318 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+	"yato/config"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var communityTabs = []string{"Reviews", "News", "Episodes", "Promos"}
+
+// communityNewsTitles is how many watched titles the news tab covers
+const communityNewsTitles = 5
+
+// CommunityScreen shows recent reviews, news, episodes and promos from Jikan
+type CommunityScreen struct {
+	tab       int
+	mediaType string
+	reviews   map[string][]lib.Review
+	news      []lib.NewsItem
+	episodes  []lib.EpisodeRelease
+	promos    []lib.Promo
+	loaded    map[string]bool
+	revealed  map[int]bool
+	cursor    int
+	offset    int
+	message   string
+}
+
+type communityLoadedMsg struct {
+	key      string
+	reviews  []lib.Review
+	news     []lib.NewsItem
+	episodes []lib.EpisodeRelease
+	promos   []lib.Promo
+	err      error
+}
+
+func communityScreen() tea.Model {
+	return CommunityScreen{
+		mediaType: "anime",
+		reviews:   map[string][]lib.Review{},
+		loaded:    map[string]bool{},
+		revealed:  map[int]bool{},
+	}
+}
+
+func (c CommunityScreen) Init() tea.Cmd {
+	return c.load()
+}
+
+// key identifies the data shown by the current tab
+func (c CommunityScreen) key() string {
+	if c.tab == 0 {
+		return "reviews/" + c.mediaType
+	}
+	return strings.ToLower(communityTabs[c.tab])
+}
+
+// load fetches the data of the current tab unless it is already loaded
+func (c CommunityScreen) load() tea.Cmd {
+	key := c.key()
+	if c.loaded[key] {
+		return nil
+	}
+
+	mediaType := c.mediaType
+	return func() tea.Msg {
+		msg := communityLoadedMsg{key: key}
+		switch key {
+		case "news":
+			list, err := lib.LoadList("anime")
+			if err != nil {
+				return communityLoadedMsg{key: key, err: err}
+			}
+			msg.news, msg.err = lib.GetListNews(list, communityNewsTitles)
+		case "episodes":
+			msg.episodes, msg.err = lib.GetRecentEpisodes()
+		case "promos":
+			msg.promos, msg.err = lib.GetRecentPromos()
+		default:
+			msg.reviews, msg.err = lib.GetRecentReviews(mediaType)
+		}
+		return msg
+	}
+}
+
+func (c CommunityScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			c.cursor--
+		case "down", "j":
+			c.cursor++
+		case "left", "right":
+			if msg.String() == "left" {
+				c.tab = (c.tab + len(communityTabs) - 1) % len(communityTabs)
+			} else {
+				c.tab = (c.tab + 1) % len(communityTabs)
+			}
+			c.cursor, c.offset, c.message = 0, 0, ""
+			return c, c.load()
+		case "tab":
+			if c.tab == 0 {
+				if c.mediaType == "anime" {
+					c.mediaType = "manga"
+				} else {
+					c.mediaType = "anime"
+				}
+				c.cursor, c.offset, c.message = 0, 0, ""
+				return c, c.load()
+			}
+		case "v":
+			if reviews := c.reviews[c.mediaType]; c.tab == 0 && len(reviews) > 0 {
+				id := reviews[c.cursor].MALId
+				c.revealed[id] = !c.revealed[id]
+			}
+		}
+		c.scroll()
+	case communityLoadedMsg:
+		c.loaded[msg.key] = msg.err == nil
+		if msg.err != nil && msg.key == c.key() {
+			c.message = msg.err.Error()
+		}
+		switch {
+		case msg.key == "news":
+			c.news = msg.news
+		case msg.key == "episodes":
+			c.episodes = msg.episodes
+		case msg.key == "promos":
+			c.promos = msg.promos
+		default:
+			c.reviews[strings.TrimPrefix(msg.key, "reviews/")] = msg.reviews
+		}
+		c.scroll()
+	}
+
+	return c, nil
+}
+
+// rows returns one line per item of the current tab
+func (c CommunityScreen) rows() []string {
+	var rows []string
+	switch c.tab {
+	case 0:
+		for _, review := range c.reviews[c.mediaType] {
+			rows = append(rows, fmt.Sprintf("%-2d %s by %s", review.Score, review.Entry.Title, review.User.Username))
+		}
+	case 1:
+		for _, item := range c.news {
+			rows = append(rows, fmt.Sprintf("%s  %s: %s", formatDate(item.Date), item.Anime, item.Title))
+		}
+	case 2:
+		for _, release := range c.episodes {
+			episodes := make([]string, len(release.Episodes))
+			for i, episode := range release.Episodes {
+				episodes[i] = episode.Title
+			}
+			rows = append(rows, fmt.Sprintf("%s: %s", release.Entry.Title, strings.Join(episodes, ", ")))
+		}
+	case 3:
+		for _, promo := range c.promos {
+			rows = append(rows, fmt.Sprintf("%s: %s", promo.Entry.Title, promo.Title))
+		}
+	}
+	return rows
+}
+
+// detail renders the selected item in full
+func (c CommunityScreen) detail(width int) string {
+	style := lipgloss.NewStyle().Width(width)
+	faint := lipgloss.NewStyle().Faint(true)
+
+	switch c.tab {
+	case 0:
+		reviews := c.reviews[c.mediaType]
+		if len(reviews) == 0 {
+			return ""
+		}
+		review := reviews[c.cursor]
+		heading := fmt.Sprintf("%s · %d/10 · %s", review.Entry.Title, review.Score, formatDate(review.Date))
+		if len(review.Tags) > 0 {
+			heading += " · " + strings.Join(review.Tags, ", ")
+		}
+
+		text := review.Review
+		switch {
+		case c.revealed[review.MALId]:
+		case review.IsSpoiler:
+			text = "This review contains spoilers. Press [v] to reveal it."
+		default:
+			text = lib.MaskSpoilers(text)
+		}
+		return faint.Render(heading) + "\n" + clampLines(style.Render(text), max(3, globals.height-c.pageSize()-9))
+	case 1:
+		if len(c.news) == 0 {
+			return ""
+		}
+		item := c.news[c.cursor]
+		return faint.Render(fmt.Sprintf("%s · by %s · %d comments", item.URL, item.AuthorUsername, item.Comments)) + "\n" +
+			style.Render(item.Excerpt)
+	case 2:
+		if len(c.episodes) == 0 {
+			return ""
+		}
+		release := c.episodes[c.cursor]
+		lines := []string{faint.Render(release.Entry.URL)}
+		for _, episode := range release.Episodes {
+			line := episode.Title
+			if episode.Premium {
+				line += " (premium)"
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\n")
+	case 3:
+		if len(c.promos) == 0 {
+			return ""
+		}
+		promo := c.promos[c.cursor]
+		return faint.Render(promo.Entry.URL) + "\n" + promo.Trailer.URL
+	}
+	return ""
+}
+
+// pageSize returns how many items are listed, leaving the rest of the screen
+// to the selected one
+func (c CommunityScreen) pageSize() int {
+	return max(3, (globals.height-6)/2)
+}
+
+func (c *CommunityScreen) scroll() {
+	count := len(c.rows())
+	c.cursor = max(0, min(c.cursor, count-1))
+
+	rows := c.pageSize()
+	if c.cursor < c.offset {
+		c.offset = c.cursor
+	} else if c.cursor >= c.offset+rows {
+		c.offset = c.cursor - rows + 1
+	}
+	c.offset = max(0, min(c.offset, count-rows))
+}
+
+func (c CommunityScreen) View() string {
+	selectedStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Bold(true)
+	activeStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Underline(true)
+
+	tabs := make([]string, len(communityTabs))
+	for i, tab := range communityTabs {
+		if i == 0 {
+			tab += " (" + c.mediaType + ")"
+		}
+		if i == c.tab {
+			tab = activeStyle.Render(tab)
+		}
+		tabs[i] = tab
+	}
+
+	width := max(20, globals.width-2)
+	rows := c.rows()
+
+	var content strings.Builder
+	content.WriteString(strings.Join(tabs, " · ") + "\n")
+
+	end := min(len(rows), c.offset+c.pageSize())
+	for i := c.offset; i < end; i++ {
+		line := truncate(rows[i], width)
+		if i == c.cursor {
+			line = selectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line + "\n")
+	}
+
+	if !c.loaded[c.key()] && c.message == "" {
+		content.WriteString("Loading...\n")
+	} else if len(rows) == 0 {
+		content.WriteString("Nothing here.\n")
+	} else {
+		content.WriteString("\n" + c.detail(width) + "\n")
+	}
+
+	help := "[←/→] tab"
+	if c.tab == 0 {
+		help += "  [tab] anime/manga  [v] reveal spoilers"
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		content.String(),
+		help,
+		statusBar(c.message),
+	)
+}
+
+// formatDate shortens an ISO 8601 timestamp to its date
+func formatDate(date string) string {
+	if len(date) >= 10 {
+		return date[:10]
+	}
+	return date
+}
+
+// clampLines keeps the first n lines of wrapped text, marking the cut
+func clampLines(text string, n int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) <= n {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(lines[:n], "\n") + "\n…"
+}
@@ -2,6 +2,8 @@ package screens
 
 import (
 	"fmt"
+	"image"
+	"sort"
 	"yato/config"
 	"yato/lib"
 
@@ -9,90 +11,214 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// homeRecommendations is how many recommendations are shown per media type
+const homeRecommendations = 5
+
 type HomeScreen struct {
 	RecentAnimeRecommendations []lib.Recommendation
 	RecentMangaRecommendations []lib.Recommendation
 	imageCache                 *lib.ImageCache
 	imageRenderer              *lib.ImageRenderer
+	lists                      map[string]*lib.UserList
+	pending                    []lib.PendingUpdate
+	hideKnown                  bool
 }
 
 func homeScreen() tea.Model {
 	recentAnimeRecommendations, _ := lib.GetRecentAnimeRecommendations()
 	recentMangaRecommendations, _ := lib.GetRecentMangaRecommendations()
-	imageCache := lib.NewImageCache()
-	imageRenderer := lib.NewImageRenderer()
 
-	return HomeScreen{
+	screen := HomeScreen{
 		RecentAnimeRecommendations: recentAnimeRecommendations,
 		RecentMangaRecommendations: recentMangaRecommendations,
-		imageCache:                 imageCache,
-		imageRenderer:              imageRenderer,
+		imageCache:                 globals.imageCache,
+		imageRenderer:              globals.imageRenderer,
+		lists:                      map[string]*lib.UserList{},
+	}
+	for _, mediaType := range []string{"anime", "manga"} {
+		list, _ := lib.LoadList(mediaType)
+		if list == nil {
+			list = &lib.UserList{MediaType: mediaType}
+		}
+		screen.lists[mediaType] = list
 	}
+	screen.pending, _ = lib.PendingUpdates()
+
+	return screen
 }
 
 func (h HomeScreen) Init() tea.Cmd {
-	return nil
+	return h.loadCovers()
 }
 
-func (h HomeScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if msg, ok := msg.(tea.KeyMsg); ok {
-		if msg.String() == "q" {
-			return h, tea.Quit
+// loadCovers downloads the covers shown that aren't cached yet
+func (h HomeScreen) loadCovers() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, req := range h.coverRequests() {
+		cmds = append(cmds, loadCover(req.MediaType, req.MALID, req.Size, req.URL))
+	}
+	return tea.Batch(cmds...)
+}
+
+// entryStatus describes where a recommended title stands on the user's list:
+// "watched" or "read" when completed, "on list" or "new"
+func (h HomeScreen) entryStatus(mediaType string, malID int) string {
+	var status lib.ListStatus
+	if entry := h.lists[mediaType].Find(malID); entry != nil {
+		status = entry.ListStatus
+	}
+	status = lib.ApplyPending(h.pending, mediaType, malID, status)
+
+	switch status.Status {
+	case "":
+		return "new"
+	case "completed":
+		if mediaType == "manga" {
+			return "read"
 		}
+		return "watched"
+	default:
+		return "on list"
 	}
+}
 
-	return h, nil
+// recommendations returns the recommendations to show, most actionable
+// first: pairs where the user completed one title and doesn't know the
+// other come before the rest. Each pair is turned so the title the user
+// knows best comes first.
+func (h HomeScreen) recommendations(mediaType string) []lib.Recommendation {
+	recs := h.RecentAnimeRecommendations
+	if mediaType == "manga" {
+		recs = h.RecentMangaRecommendations
+	}
+
+	rank := map[string]int{"watched": 0, "read": 0, "on list": 1, "new": 2}
+	type pair struct {
+		rec   lib.Recommendation
+		order int
+	}
+
+	var pairs []pair
+	for _, rec := range recs {
+		if len(rec.Entry) < 2 {
+			continue
+		}
+		first := rank[h.entryStatus(mediaType, rec.Entry[0].MALId)]
+		second := rank[h.entryStatus(mediaType, rec.Entry[1].MALId)]
+		if h.hideKnown && first < 2 && second < 2 {
+			continue
+		}
+		if second < first {
+			rec.Entry = []lib.JikanEntry{rec.Entry[1], rec.Entry[0]}
+			first, second = second, first
+		}
+
+		// A completed title recommending a new one is the most useful, then
+		// any pair with a completed title, then one on the list and a new one
+		order := 3
+		switch {
+		case first == 0 && second == 2:
+			order = 0
+		case first == 0:
+			order = 1
+		case first == 1 && second == 2:
+			order = 2
+		}
+		pairs = append(pairs, pair{rec: rec, order: order})
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].order < pairs[j].order
+	})
+
+	result := make([]lib.Recommendation, 0, min(homeRecommendations, len(pairs)))
+	for _, p := range pairs[:min(homeRecommendations, len(pairs))] {
+		result = append(result, p.rec)
+	}
+	return result
 }
 
-func (h HomeScreen) View() string {
-	w := lipgloss.Width
+// coverRequests lists the covers shown on the home screen
+func (h HomeScreen) coverRequests() []lib.ImageRequest {
+	var requests []lib.ImageRequest
+	add := func(mediaType string, recs []lib.Recommendation) {
+		for _, rec := range recs {
+			requests = append(requests, lib.ImageRequest{
+				MediaType: mediaType,
+				MALID:     rec.Entry[0].MALId,
+				Size:      "small",
+				URL:       rec.Entry[0].Images.URL("small", config.GetConfig().Cache.PreferWebP),
+			})
+		}
+	}
+	add("anime", h.recommendations("anime"))
+	add("manga", h.recommendations("manga"))
 
-	// Top bar, Content, Status bar
-	topBarStyle := lipgloss.NewStyle().
-		Foreground(config.Colors.Text).
-		Background(config.Colors.Primary)
+	return requests
+}
 
-	mainText := topBarStyle.Padding(0, 0, 0, 1).Render(config.PrettyAppName + " | [H]ome | [A]nime | [M]anga | [S]earch | [C]ommunity | [P]rofile | [O]ptions | [Q]uit")
-	userText := topBarStyle.Padding(0, 1, 0, 0).Render("User: " + globals.CurrentUser.Name + " | [L]ogout")
-	separator := topBarStyle.Width(globals.width - w(mainText) - w(userText)).Render("")
+func (h HomeScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "." {
+			h.hideKnown = !h.hideKnown
+			return h, h.loadCovers()
+		}
+	case listSyncedMsg:
+		if msg.list != nil {
+			h.lists[msg.mediaType] = msg.list
+		}
+		return h, h.loadCovers()
+	case pendingSyncedMsg:
+		h.pending, _ = lib.PendingUpdates()
+		return h, h.loadCovers()
+	}
 
-	topBar := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		mainText,
-		separator,
-		userText,
-	)
+	return h, nil
+}
 
+func (h HomeScreen) View() string {
 	content := ""
-	// Top 5 recommendations
-	for i, rec := range h.RecentAnimeRecommendations {
-		if i == 5 {
-			break
-		}
+	for _, rec := range h.recommendations("anime") {
 		content += h.renderRecommendation("anime", rec)
 	}
 
-	for i, rec := range h.RecentMangaRecommendations {
-		if i == 5 {
-			break
-		}
+	for _, rec := range h.recommendations("manga") {
 		content += h.renderRecommendation("manga", rec)
 	}
 
+	help := "[.] hide recommendations between titles you know"
+	if h.hideKnown {
+		help = "[.] show all recommendations"
+	}
+
+	// Top bar, Content, Status bar
 	return lipgloss.JoinVertical(
 		lipgloss.Top,
-		topBar,
+		topBar(),
 		content,
+		help,
+		statusBar(h.imageRenderer.Warning()),
 	)
 
 }
 
 func (h HomeScreen) renderRecommendation(mediaType string, rec lib.Recommendation) string {
-	img, err := h.imageCache.GetImage(mediaType, rec.Entry[0].MALId, "small", rec.Entry[0].Images.JPG.SmallImageURL)
+	pair := fmt.Sprintf("%s (%s) -> %s (%s)\n",
+		rec.Entry[0].Title, h.entryStatus(mediaType, rec.Entry[0].MALId),
+		rec.Entry[1].Title, h.entryStatus(mediaType, rec.Entry[1].MALId))
+	if h.imageCache == nil {
+		return pair
+	}
+
+	entry := rec.Entry[0]
+	key := fmt.Sprintf("%s/%d/small", mediaType, entry.MALId)
+	renderedImage, err := h.imageRenderer.RenderCached(key, 10, 7, func() (image.Image, error) {
+		return h.imageCache.CachedImage(mediaType, entry.MALId, "small")
+	})
 	if err != nil {
-		return fmt.Sprintf("%s -> %s\n", rec.Entry[0].Title, rec.Entry[1].Title)
+		return pair
 	}
 
-	renderedImage := h.imageRenderer.RenderImage(img, 20, 30)
-	return fmt.Sprintf("%s%s -> %s\n", renderedImage, rec.Entry[0].Title, rec.Entry[1].Title)
+	return renderedImage + pair
 }
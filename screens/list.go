@@ -0,0 +1,364 @@
+package screens
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"yato/config"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// listStatuses are the list statuses the list screens can filter by, with an
+// empty status meaning all entries
+var listStatuses = map[string][]string{
+	"anime": append([]string{""}, lib.AnimeStatuses...),
+	"manga": append([]string{""}, lib.MangaStatuses...),
+}
+
+// ListScreen shows the user's anime or manga list from the local mirror and
+// refreshes it in the background
+type ListScreen struct {
+	mediaType  string
+	list       *lib.UserList
+	pending    []lib.PendingUpdate
+	filter     int
+	cursor     int
+	offset     int
+	syncing    bool
+	message    string
+	prefetcher *lib.Prefetcher
+}
+
+type listSyncedMsg struct {
+	mediaType string
+	list      *lib.UserList
+	err       error
+}
+
+type listUpdatedMsg struct {
+	mediaType string
+	err       error
+}
+
+func listScreen(mediaType string) tea.Model {
+	screen := ListScreen{mediaType: mediaType, syncing: true}
+
+	list, err := lib.LoadList(mediaType)
+	if err != nil {
+		screen.message = err.Error()
+		list = &lib.UserList{MediaType: mediaType}
+	}
+	screen.list = list
+	screen.pending, _ = lib.PendingUpdates()
+
+	if globals.imageCache != nil {
+		screen.prefetcher = globals.imageCache.NewPrefetcher(listPageSize())
+	}
+
+	return screen
+}
+
+func (l ListScreen) Init() tea.Cmd {
+	l.prefetch()
+	return tea.Batch(syncList(l.mediaType), l.loadCover())
+}
+
+func syncList(mediaType string) tea.Cmd {
+	return func() tea.Msg {
+		list, err := lib.SyncList(mediaType)
+		return listSyncedMsg{mediaType: mediaType, list: list, err: err}
+	}
+}
+
+func (l ListScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		entries := l.entries()
+		switch msg.String() {
+		case "up", "k":
+			l.cursor--
+		case "down", "j":
+			l.cursor++
+		case "pgup":
+			l.cursor -= listPageSize()
+		case "pgdown":
+			l.cursor += listPageSize()
+		case "tab":
+			l.filter = (l.filter + 1) % len(listStatuses[l.mediaType])
+			l.cursor, l.offset = 0, 0
+		case "r":
+			l.syncing = true
+			return l, syncList(l.mediaType)
+		case "+", "=":
+			if len(entries) > 0 {
+				return l.changeProgress(entries[l.cursor], 1)
+			}
+		case "-":
+			if len(entries) > 0 {
+				return l.changeProgress(entries[l.cursor], -1)
+			}
+		}
+		l.scroll()
+	case tea.WindowSizeMsg:
+		l.scroll()
+	case listSyncedMsg:
+		if msg.mediaType != l.mediaType {
+			break
+		}
+		l.syncing = false
+		if msg.list != nil {
+			l.list = msg.list
+		}
+		if msg.err != nil {
+			l.message = "Sync failed: " + msg.err.Error()
+		}
+		l.scroll()
+	case listUpdatedMsg:
+		if msg.mediaType != l.mediaType {
+			break
+		}
+		if errors.Is(msg.err, lib.ErrUpdateQueued) {
+			l.message = "Saved offline, will sync when back online"
+		} else if msg.err != nil {
+			l.message = "Update failed: " + msg.err.Error()
+		}
+		l.reload()
+	case pendingSyncedMsg:
+		l.reload()
+	case coverLoadedMsg:
+		// Drawn again as is, a failed download is retried on the next move
+		return l, nil
+	}
+
+	return l, l.loadCover()
+}
+
+// loadCover downloads the selected title's cover if it isn't cached yet
+func (l ListScreen) loadCover() tea.Cmd {
+	entries := l.entries()
+	if len(entries) == 0 {
+		return nil
+	}
+	entry := entries[l.cursor]
+	return loadCover(l.mediaType, entry.Node.ID, "medium", entry.Node.MainPicture.Medium)
+}
+
+// changeProgress adds delta to the episodes watched or chapters read of entry,
+// showing the change right away while it is sent to MAL
+func (l ListScreen) changeProgress(entry lib.ListEntry, delta int) (tea.Model, tea.Cmd) {
+	progress := entry.ListStatus.Progress() + delta
+	if total := entry.Node.Total(); total > 0 {
+		progress = min(progress, total)
+	}
+	progress = max(progress, 0)
+
+	field := "num_watched_episodes"
+	if l.mediaType == "manga" {
+		field = "num_chapters_read"
+	}
+
+	// Pending updates are already applied to entry, so take the base
+	// timestamp from the mirror, which only holds what MAL confirmed
+	base := ""
+	if stored := l.list.Find(entry.Node.ID); stored != nil {
+		base = stored.ListStatus.UpdatedAt
+	}
+
+	update := lib.ListUpdate{
+		MediaType:     l.mediaType,
+		MALID:         entry.Node.ID,
+		Title:         entry.Node.Title,
+		Fields:        map[string]string{field: strconv.Itoa(progress)},
+		BaseUpdatedAt: base,
+	}
+	l.pending = append(l.pending, lib.PendingUpdate{Update: update})
+	l.message = ""
+
+	mediaType := l.mediaType
+	return l, func() tea.Msg {
+		_, err := lib.SubmitListUpdate(update)
+		return listUpdatedMsg{mediaType: mediaType, err: err}
+	}
+}
+
+// reload rereads the local mirror and the journal after an update, which
+// may have moved entries out of the current tab
+func (l *ListScreen) reload() {
+	if list, err := lib.LoadList(l.mediaType); err == nil {
+		l.list = list
+	}
+	l.pending, _ = lib.PendingUpdates()
+	l.scroll()
+}
+
+// entries returns the entries matching the current filter, with pending
+// updates applied
+func (l ListScreen) entries() []lib.ListEntry {
+	filtered := l.list.WithStatus(listStatuses[l.mediaType][l.filter])
+
+	entries := make([]lib.ListEntry, len(filtered))
+	for i, entry := range filtered {
+		entry.ListStatus = lib.ApplyPending(l.pending, l.mediaType, entry.Node.ID, entry.ListStatus)
+		entries[i] = entry
+	}
+	return entries
+}
+
+// scroll keeps the cursor in range and visible, and prefetches the covers
+// around the new window
+func (l *ListScreen) scroll() {
+	count := len(l.entries())
+	l.cursor = max(0, min(l.cursor, count-1))
+
+	rows := listPageSize()
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	} else if l.cursor >= l.offset+rows {
+		l.offset = l.cursor - rows + 1
+	}
+	l.offset = max(0, min(l.offset, count-rows))
+
+	l.prefetch()
+}
+
+func (l ListScreen) prefetch() {
+	if l.prefetcher == nil {
+		return
+	}
+
+	entries := l.entries()
+	requests := make([]lib.ImageRequest, len(entries))
+	for i, entry := range entries {
+		requests[i] = lib.ImageRequest{
+			MediaType: l.mediaType,
+			MALID:     entry.Node.ID,
+			Size:      "medium",
+			URL:       entry.Node.MainPicture.Medium,
+		}
+	}
+	l.prefetcher.SetWindow(requests, l.offset, l.offset+listPageSize())
+}
+
+// listPageSize is the number of rows that fit below the header, above the
+// selected title's details
+func listPageSize() int {
+	return max(1, globals.height-coverRows-6)
+}
+
+const (
+	coverColumns = 10
+	coverRows    = 7
+)
+
+func (l ListScreen) View() string {
+	selectedStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Bold(true)
+	headerStyle := lipgloss.NewStyle().Bold(true)
+
+	var tabs []string
+	for i, status := range listStatuses[l.mediaType] {
+		label := statusLabel(status)
+		if i == l.filter {
+			label = selectedStyle.Render("[" + label + "]")
+		}
+		tabs = append(tabs, label)
+	}
+
+	entries := l.entries()
+	progressHeader := "Episodes"
+	if l.mediaType == "manga" {
+		progressHeader = "Chapters"
+	}
+	titleWidth := max(20, globals.width-40)
+
+	var content strings.Builder
+	content.WriteString(strings.Join(tabs, "  ") + "\n")
+	content.WriteString(headerStyle.Render(fmt.Sprintf("  %-*s %-14s %-10s %s", titleWidth, "Title", "Status", progressHeader, "Score")) + "\n")
+
+	end := min(len(entries), l.offset+listPageSize())
+	for i := l.offset; i < end; i++ {
+		entry := entries[i]
+		score := "-"
+		if entry.ListStatus.Score > 0 {
+			score = strconv.Itoa(entry.ListStatus.Score)
+		}
+
+		line := fmt.Sprintf("%-*s %-14s %-10s %s", titleWidth, truncate(entry.Node.Title, titleWidth),
+			statusLabel(entry.ListStatus.Status), fmt.Sprintf("%d/%s", entry.ListStatus.Progress(), formatTotal(entry.Node.Total())), score)
+		if i == l.cursor {
+			line = selectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line + "\n")
+	}
+
+	if len(entries) == 0 {
+		if l.syncing {
+			content.WriteString("Loading your list...\n")
+		} else {
+			content.WriteString("Nothing here.\n")
+		}
+	} else {
+		content.WriteString(l.renderCover(entries[l.cursor]))
+	}
+
+	message := l.message
+	if l.syncing && message == "" {
+		message = "Syncing..."
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		content.String(),
+		"[tab] filter  [+/-] progress  [r] refresh",
+		statusBar(message),
+	)
+}
+
+func (l ListScreen) renderCover(entry lib.ListEntry) string {
+	if globals.imageCache == nil || entry.Node.MainPicture.Medium == "" {
+		return ""
+	}
+
+	key := fmt.Sprintf("%s/%d/medium", l.mediaType, entry.Node.ID)
+	rendered, err := globals.imageRenderer.RenderCached(key, coverColumns, coverRows, func() (image.Image, error) {
+		return globals.imageCache.CachedImage(l.mediaType, entry.Node.ID, "medium")
+	})
+	if err != nil {
+		return ""
+	}
+
+	return rendered
+}
+
+// statusLabel turns a list status such as "plan_to_watch" into "Plan to watch"
+func statusLabel(status string) string {
+	if status == "" {
+		return "All"
+	}
+	label := strings.ReplaceAll(status, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// formatTotal renders an episode or chapter count, which is 0 when unknown
+func formatTotal(total int) string {
+	if total == 0 {
+		return "?"
+	}
+	return strconv.Itoa(total)
+}
+
+// truncate shortens s to at most width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:max(0, width-1)]) + "…"
+}
@@ -0,0 +1,108 @@
+package screens
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yato/lib"
+)
+
+// benchListScreen returns an anime list screen of n titles whose covers are
+// all on disk, as they are once the prefetcher has caught up
+func benchListScreen(b *testing.B, n int) ListScreen {
+	b.Setenv("YATO_CACHE_DIR", b.TempDir())
+	b.Setenv("TERM", "xterm-kitty")
+	b.Setenv("TMUX", "")
+	b.Setenv("STY", "")
+
+	img := image.NewRGBA(image.Rect(0, 0, 225, 320))
+	for y := 0; y < 320; y++ {
+		for x := 0; x < 225; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+	var cover bytes.Buffer
+	png.Encode(&cover, img)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(cover.Bytes())
+	}))
+	b.Cleanup(server.Close)
+
+	saved := globals
+	b.Cleanup(func() { globals = saved })
+
+	cache, err := lib.NewImageCache()
+	if err != nil {
+		b.Fatal(err)
+	}
+	globals = Globals{width: 120, height: 40, imageCache: cache, imageRenderer: lib.NewImageRenderer()}
+
+	list := &lib.UserList{MediaType: "anime"}
+	for i := 1; i <= n; i++ {
+		entry := lib.ListEntry{}
+		entry.Node.ID = i
+		entry.Node.Title = fmt.Sprintf("Title %d", i)
+		entry.Node.MainPicture.Medium = server.URL + fmt.Sprintf("/%d.png", i)
+		entry.ListStatus.Status = "watching"
+		list.Entries = append(list.Entries, entry)
+
+		if _, err := cache.GetImage("anime", i, "medium", entry.Node.MainPicture.Medium); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return ListScreen{mediaType: "anime", list: list}
+}
+
+// BenchmarkListView measures drawing the list screen, which happens on every
+// key press. Moving the cursor draws a different cover each frame.
+func BenchmarkListView(b *testing.B) {
+	b.Run("still", func(b *testing.B) {
+		screen := benchListScreen(b, 50)
+		screen.View()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			screen.View()
+		}
+	})
+
+	b.Run("scrolling", func(b *testing.B) {
+		screen := benchListScreen(b, 50)
+		for screen.cursor = 0; screen.cursor < 50; screen.cursor++ {
+			screen.View()
+		}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			screen.cursor = i % 50
+			screen.scroll()
+			screen.View()
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"Monster", 10, "Monster"},
+		{"Monster", 7, "Monster"},
+		{"Monster", 5, "Mons…"},
+		{"進撃の巨人", 3, "進撃…"},
+		{"Monster", 0, "…"},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.width); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
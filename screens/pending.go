@@ -0,0 +1,122 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+	"yato/config"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PendingScreen lists list updates that haven't reached MAL yet and lets the
+// user retry, force or discard them
+type PendingScreen struct {
+	updates []lib.PendingUpdate
+	cursor  int
+	message string
+}
+
+func pendingScreen() tea.Model {
+	updates, err := lib.PendingUpdates()
+	screen := PendingScreen{updates: updates}
+	if err != nil {
+		screen.message = err.Error()
+	}
+
+	return screen
+}
+
+func (p PendingScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (p PendingScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "down", "j":
+			if p.cursor < len(p.updates)-1 {
+				p.cursor++
+			}
+		case "r":
+			p.message = "Syncing..."
+			return p, syncPending()
+		case "f":
+			if len(p.updates) > 0 {
+				p.message = "Syncing..."
+				return p, syncPending(p.updates[p.cursor].ID)
+			}
+		case "x":
+			if len(p.updates) > 0 {
+				if err := lib.DiscardPending(p.updates[p.cursor].ID); err != nil {
+					p.message = err.Error()
+				} else {
+					p.message = "Discarded " + p.updates[p.cursor].Update.String()
+				}
+				p.reload()
+			}
+		}
+	case pendingSyncedMsg:
+		if msg.err != nil {
+			p.message = msg.err.Error()
+		} else {
+			p.message = fmt.Sprintf("Synced %d, %d conflicting, %d remaining", msg.result.Applied, msg.result.Conflicts, msg.result.Remaining)
+		}
+		p.reload()
+	}
+
+	return p, nil
+}
+
+func (p *PendingScreen) reload() {
+	updates, err := lib.PendingUpdates()
+	if err != nil {
+		p.message = err.Error()
+		return
+	}
+
+	p.updates = updates
+	globals.pendingCount = len(updates)
+	p.cursor = max(0, min(p.cursor, len(p.updates)-1))
+}
+
+func (p PendingScreen) View() string {
+	selectedStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Bold(true)
+	conflictStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+
+	var content strings.Builder
+	if len(p.updates) == 0 {
+		content.WriteString("No pending changes, your list is in sync with MyAnimeList.\n")
+	}
+	for i, update := range p.updates {
+		line := fmt.Sprintf("%s  %s", update.QueuedAt.Format("2006-01-02 15:04"), update.Update.String())
+		if update.Conflict {
+			line += conflictStyle.Render("  [changed on MAL, press f to overwrite]")
+		} else if update.Error != "" {
+			line += conflictStyle.Render("  [" + update.Error + "]")
+		}
+
+		if i == p.cursor {
+			line = selectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line + "\n")
+	}
+
+	help := "[r] sync now  [f] force selected  [x] discard selected"
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		content.String(),
+		help,
+		statusBar(p.message),
+	)
+}
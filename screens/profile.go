@@ -0,0 +1,139 @@
+package screens
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"time"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	avatarColumns = 20
+	avatarRows    = 10
+)
+
+// ProfileScreen shows the user's MAL profile and anime statistics
+type ProfileScreen struct {
+	user        *lib.MALUser
+	avatarReady bool
+	loading     bool
+	message     string
+}
+
+type profileLoadedMsg struct {
+	user        *lib.MALUser
+	avatarReady bool
+	err         error
+}
+
+func profileScreen() tea.Model {
+	return ProfileScreen{user: globals.CurrentUser, loading: true}
+}
+
+// Init refetches the profile, as the statistics change while using the
+// app, and downloads the avatar before it is drawn
+func (p ProfileScreen) Init() tea.Cmd {
+	return func() tea.Msg {
+		user, err := lib.RefreshCurrentUser()
+		if err != nil {
+			return profileLoadedMsg{err: err}
+		}
+
+		ready := false
+		if globals.imageCache != nil && user.Picture != "" {
+			_, imgErr := globals.imageCache.GetImage("user", user.ID, "avatar", user.Picture)
+			ready = imgErr == nil
+		}
+		return profileLoadedMsg{user: user, avatarReady: ready}
+	}
+}
+
+func (p ProfileScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case profileLoadedMsg:
+		p.loading = false
+		if msg.err != nil {
+			p.message = msg.err.Error()
+			break
+		}
+		p.user = msg.user
+		p.avatarReady = msg.avatarReady
+		globals.CurrentUser = msg.user
+		if err := lib.RememberUser(msg.user.ID); err != nil {
+			p.message = err.Error()
+		}
+	}
+
+	return p, nil
+}
+
+func (p ProfileScreen) avatar() string {
+	if !p.avatarReady {
+		return ""
+	}
+	user := p.user
+	key := fmt.Sprintf("user/%d/avatar", user.ID)
+	rendered, err := globals.imageRenderer.RenderCached(key, avatarColumns, avatarRows, func() (image.Image, error) {
+		return globals.imageCache.CachedImage("user", user.ID, "avatar")
+	})
+	if err != nil {
+		return ""
+	}
+	return rendered
+}
+
+func (p ProfileScreen) View() string {
+	if p.user == nil {
+		message := "Loading..."
+		if !p.loading {
+			message = "Couldn't load your profile."
+		}
+		return lipgloss.JoinVertical(lipgloss.Top, topBar(), message, statusBar(p.message))
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	labelStyle := lipgloss.NewStyle().Faint(true)
+	row := func(label string, value any) string {
+		return labelStyle.Render(fmt.Sprintf("%-16s", label)) + fmt.Sprint(value)
+	}
+
+	user, stats := p.user, p.user.AnimeStatistics
+
+	lines := []string{headerStyle.Render(user.Name)}
+	if joined, err := time.Parse(time.RFC3339, user.JoinedAt); err == nil {
+		lines = append(lines, row("Joined", joined.Format("January 2, 2006")))
+	}
+	if user.Location != "" {
+		lines = append(lines, row("Location", user.Location))
+	}
+	if user.Birthday != "" {
+		lines = append(lines, row("Birthday", user.Birthday))
+	}
+
+	lines = append(lines,
+		"",
+		headerStyle.Render("Anime"),
+		row("Days watched", fmt.Sprintf("%.1f", stats.NumDaysWatched)),
+		row("Mean score", formatMean(stats.MeanScore)),
+		row("Episodes", stats.NumEpisodes),
+		row("Rewatched", stats.NumTimesRewatched),
+		"",
+		row("Watching", stats.NumItemsWatching),
+		row("Completed", stats.NumItemsCompleted),
+		row("On hold", stats.NumItemsOnHold),
+		row("Dropped", stats.NumItemsDropped),
+		row("Plan to watch", stats.NumItemsPlanToWatch),
+		row("Total", stats.NumItems),
+	)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		p.avatar()+strings.Join(lines, "\n"),
+		statusBar(p.message, globals.imageRenderer.Warning()),
+	)
+}
@@ -0,0 +1,225 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+	"yato/config"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const rankingPageSize = 50
+
+// RankingsScreen shows MAL's top anime and manga
+type RankingsScreen struct {
+	mediaType   string
+	rankingType int
+	titles      []lib.Title
+	hasNext     bool
+	loading     bool
+	lists       map[string]*lib.UserList
+	pending     []lib.PendingUpdate
+	cursor      int
+	offset      int
+	message     string
+}
+
+type rankingLoadedMsg struct {
+	mediaType   string
+	rankingType string
+	offset      int
+	titles      []lib.Title
+	hasNext     bool
+	err         error
+}
+
+func rankingsScreen() tea.Model {
+	screen := RankingsScreen{mediaType: "anime", lists: map[string]*lib.UserList{}, loading: true}
+
+	for _, mediaType := range []string{"anime", "manga"} {
+		list, _ := lib.LoadList(mediaType)
+		if list == nil {
+			list = &lib.UserList{MediaType: mediaType}
+		}
+		screen.lists[mediaType] = list
+	}
+	screen.pending, _ = lib.PendingUpdates()
+
+	return screen
+}
+
+func (r RankingsScreen) Init() tea.Cmd {
+	return r.load()
+}
+
+// load fetches the page following the titles loaded so far
+func (r RankingsScreen) load() tea.Cmd {
+	mediaType, rankingType, offset := r.mediaType, r.currentType(), len(r.titles)
+	return func() tea.Msg {
+		titles, hasNext, err := lib.GetRanking(mediaType, rankingType, offset, rankingPageSize)
+		return rankingLoadedMsg{
+			mediaType:   mediaType,
+			rankingType: rankingType,
+			offset:      offset,
+			titles:      titles,
+			hasNext:     hasNext,
+			err:         err,
+		}
+	}
+}
+
+func (r RankingsScreen) currentType() string {
+	return lib.RankingTypes[r.mediaType][r.rankingType]
+}
+
+// reset drops the loaded titles and starts over with the first page
+func (r RankingsScreen) reset() (tea.Model, tea.Cmd) {
+	r.titles, r.hasNext = nil, false
+	r.cursor, r.offset, r.loading = 0, 0, true
+	r.message = ""
+	return r, r.load()
+}
+
+func (r RankingsScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			r.cursor--
+		case "down", "j":
+			r.cursor++
+		case "pgup":
+			r.cursor -= listPageSize()
+		case "pgdown":
+			r.cursor += listPageSize()
+		case "left":
+			types := len(lib.RankingTypes[r.mediaType])
+			r.rankingType = (r.rankingType + types - 1) % types
+			return r.reset()
+		case "right":
+			r.rankingType = (r.rankingType + 1) % len(lib.RankingTypes[r.mediaType])
+			return r.reset()
+		case "tab":
+			if r.mediaType == "anime" {
+				r.mediaType = "manga"
+			} else {
+				r.mediaType = "anime"
+			}
+			r.rankingType = 0
+			return r.reset()
+		}
+		r.scroll()
+
+		// Load the next page before the cursor reaches the end
+		if r.hasNext && !r.loading && r.cursor >= len(r.titles)-listPageSize() {
+			r.loading = true
+			return r, r.load()
+		}
+	case tea.WindowSizeMsg:
+		r.scroll()
+	case rankingLoadedMsg:
+		if msg.mediaType != r.mediaType || msg.rankingType != r.currentType() || msg.offset != len(r.titles) {
+			break
+		}
+		r.loading = false
+		if msg.err != nil {
+			r.message = msg.err.Error()
+			break
+		}
+		r.titles = append(r.titles, msg.titles...)
+		r.hasNext = msg.hasNext
+		r.scroll()
+	case listSyncedMsg:
+		if msg.list != nil {
+			r.lists[msg.mediaType] = msg.list
+		}
+	case pendingSyncedMsg:
+		r.pending, _ = lib.PendingUpdates()
+	}
+
+	return r, nil
+}
+
+func (r *RankingsScreen) scroll() {
+	count := len(r.titles)
+	r.cursor = max(0, min(r.cursor, count-1))
+
+	rows := listPageSize()
+	if r.cursor < r.offset {
+		r.offset = r.cursor
+	} else if r.cursor >= r.offset+rows {
+		r.offset = r.cursor - rows + 1
+	}
+	r.offset = max(0, min(r.offset, count-rows))
+}
+
+// listStatus returns the user's status for a title, with pending updates
+// applied, or an empty string when it isn't on their list
+func (r RankingsScreen) listStatus(title lib.Title) string {
+	var status lib.ListStatus
+	if entry := r.lists[r.mediaType].Find(title.ID); entry != nil {
+		status = entry.ListStatus
+	}
+	status = lib.ApplyPending(r.pending, r.mediaType, title.ID, status)
+	if status.Status == "" {
+		return ""
+	}
+
+	label := statusLabel(status.Status)
+	if progress := status.Progress(); progress > 0 {
+		label += fmt.Sprintf(" %d/%s", progress, formatTotal(title.Total()))
+	}
+	if status.Score > 0 {
+		label += fmt.Sprintf(" ★%d", status.Score)
+	}
+	return label
+}
+
+func (r RankingsScreen) View() string {
+	selectedStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Bold(true)
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	activeStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Underline(true)
+
+	types := make([]string, len(lib.RankingTypes[r.mediaType]))
+	for i, rankingType := range lib.RankingTypes[r.mediaType] {
+		if i == r.rankingType {
+			rankingType = activeStyle.Render(rankingType)
+		}
+		types[i] = rankingType
+	}
+
+	titleWidth := max(20, globals.width-50)
+
+	var content strings.Builder
+	content.WriteString(headerStyle.Render("Top "+r.mediaType) + "  " + strings.Join(types, " · ") + "\n")
+	content.WriteString(headerStyle.Render(fmt.Sprintf("  %-5s %-*s %-8s %-6s %s", "Rank", titleWidth, "Title", "Type", "Score", "Your list")) + "\n")
+
+	end := min(len(r.titles), r.offset+listPageSize())
+	for i := r.offset; i < end; i++ {
+		title := r.titles[i]
+		line := fmt.Sprintf("%-5d %-*s %-8s %-6s %s", title.Rank, titleWidth, truncate(title.Title, titleWidth),
+			strings.ToUpper(title.MediaType), formatMean(title.Mean), r.listStatus(title))
+		if i == r.cursor {
+			line = selectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line + "\n")
+	}
+
+	if r.loading {
+		content.WriteString("Loading...\n")
+	} else if len(r.titles) == 0 {
+		content.WriteString("Nothing here.\n")
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		content.String(),
+		"[←/→] ranking  [tab] anime/manga",
+		statusBar(r.message),
+	)
+}
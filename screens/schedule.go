@@ -0,0 +1,190 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"yato/config"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ScheduleScreen shows when the anime the user is watching air next
+type ScheduleScreen struct {
+	list    *lib.UserList
+	pending []lib.PendingUpdate
+	started time.Time
+	syncing bool
+	cursor  int
+	offset  int
+	message string
+}
+
+// scheduleTickMsg redraws the countdowns. It carries the start time of the
+// screen that scheduled it, so ticks of a screen switched away from stop.
+type scheduleTickMsg struct {
+	started time.Time
+}
+
+func scheduleScreen() tea.Model {
+	screen := ScheduleScreen{started: time.Now(), syncing: true}
+
+	screen.list, _ = lib.LoadList("anime")
+	if screen.list == nil {
+		screen.list = &lib.UserList{MediaType: "anime"}
+	}
+	screen.pending, _ = lib.PendingUpdates()
+
+	return screen
+}
+
+func (s ScheduleScreen) Init() tea.Cmd {
+	return tea.Batch(syncList("anime"), s.tick())
+}
+
+func (s ScheduleScreen) tick() tea.Cmd {
+	started := s.started
+	return tea.Tick(time.Minute, func(time.Time) tea.Msg {
+		return scheduleTickMsg{started: started}
+	})
+}
+
+func (s ScheduleScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			s.cursor--
+		case "down", "j":
+			s.cursor++
+		case "r":
+			// Incremental syncs only refetch entries edited since the last one, so
+			// broadcast times of the others can be up to a day old. Refetch it all.
+			s.syncing = true
+			return s, func() tea.Msg {
+				list, err := lib.RefreshList("anime")
+				return listSyncedMsg{mediaType: "anime", list: list, err: err}
+			}
+		}
+		s.scroll()
+	case tea.WindowSizeMsg:
+		s.scroll()
+	case scheduleTickMsg:
+		if msg.started.Equal(s.started) {
+			return s, s.tick()
+		}
+	case listSyncedMsg:
+		if msg.mediaType != "anime" {
+			break
+		}
+		s.syncing = false
+		if msg.list != nil {
+			s.list = msg.list
+		}
+		if msg.err != nil {
+			s.message = "Sync failed: " + msg.err.Error()
+		}
+		s.scroll()
+	case pendingSyncedMsg:
+		s.pending, _ = lib.PendingUpdates()
+	}
+
+	return s, nil
+}
+
+// schedule returns the titles the user is watching that air this week, with
+// pending updates applied
+func (s ScheduleScreen) schedule() []lib.Airing {
+	schedule := lib.WeekSchedule(s.list, time.Now(), "watching")
+	for i, airing := range schedule {
+		schedule[i].Entry.ListStatus = lib.ApplyPending(s.pending, "anime", airing.Entry.Node.ID, airing.Entry.ListStatus)
+	}
+	return schedule
+}
+
+func (s *ScheduleScreen) scroll() {
+	count := len(s.schedule())
+	s.cursor = max(0, min(s.cursor, count-1))
+
+	rows := listPageSize()
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	} else if s.cursor >= s.offset+rows {
+		s.offset = s.cursor - rows + 1
+	}
+	s.offset = max(0, min(s.offset, count-rows))
+}
+
+// formatCountdown renders the time left until an airing, e.g. "2d 4h" or "35m"
+func formatCountdown(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days, hours, minutes := int(d/(24*time.Hour)), int(d/time.Hour)%24, int(d/time.Minute)%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+func (s ScheduleScreen) View() string {
+	selectedStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Bold(true)
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	behindStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary)
+
+	now := time.Now()
+	schedule := s.schedule()
+	titleWidth := max(20, globals.width-56)
+
+	var content strings.Builder
+	content.WriteString(headerStyle.Render("Airing this week, in "+now.Format("MST")) + "\n")
+	content.WriteString(headerStyle.Render(fmt.Sprintf("  %-9s %-5s %-*s %-8s %-9s %-9s %s",
+		"Day", "Time", titleWidth, "Title", "Episode", "In", "Watched", "")) + "\n")
+
+	end := min(len(schedule), s.offset+listPageSize())
+	for i := s.offset; i < end; i++ {
+		airing := schedule[i]
+		next := airing.Next.In(time.Local)
+
+		episode := "-"
+		if n := airing.NextEpisode(); n > 0 {
+			episode = fmt.Sprintf("#%d", n)
+		}
+		watched := fmt.Sprintf("%d/%s", airing.Entry.ListStatus.NumEpisodesWatched, formatTotal(airing.Entry.Node.NumEpisodes))
+		behind := ""
+		if n, ok := airing.Behind(); !ok {
+			behind = lipgloss.NewStyle().Faint(true).Render("aired episodes unknown")
+		} else if n > 0 {
+			behind = behindStyle.Render(fmt.Sprintf("%d behind", n))
+		}
+
+		line := fmt.Sprintf("%-9s %-5s %-*s %-8s %-9s %-9s ", next.Format("Monday"), next.Format("15:04"),
+			titleWidth, truncate(airing.Entry.Node.Title, titleWidth), episode, formatCountdown(next.Sub(now)), watched)
+		if i == s.cursor {
+			line = selectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line + behind + "\n")
+	}
+
+	if len(schedule) == 0 {
+		if s.syncing {
+			content.WriteString("Loading...\n")
+		} else {
+			content.WriteString("Nothing you're watching airs this week.\n")
+		}
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		content.String(),
+		"[r] refresh broadcast times",
+		statusBar(s.message),
+	)
+}
@@ -0,0 +1,27 @@
+package screens
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0m"},
+		{20 * time.Second, "0m"},
+		{45 * time.Minute, "45m"},
+		{59*time.Minute + 40*time.Second, "1h 0m"},
+		{3*time.Hour + 5*time.Minute, "3h 5m"},
+		{24 * time.Hour, "1d 0h"},
+		{6*24*time.Hour + 23*time.Hour + 59*time.Minute, "6d 23h"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCountdown(tt.d); got != tt.want {
+			t.Errorf("formatCountdown(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -2,9 +2,14 @@ package screens
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
+	"yato/config"
 	"yato/lib"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/term"
 )
 
@@ -13,15 +18,60 @@ type ScreenSwitcher struct {
 }
 
 type Globals struct {
-	width       int
-	height      int
-	CurrentUser *lib.MALUser
+	width         int
+	height        int
+	CurrentUser   *lib.MALUser
+	imageCache    *lib.ImageCache
+	imageRenderer *lib.ImageRenderer
+
+	// pendingCount is the number of queued list updates shown in the status
+	// bar, kept here so drawing never touches the journal
+	pendingCount int
 }
 
 var globals Globals
 
+// navItem is an entry of the top bar. Pressing key switches to the screen
+// built by screen, if the entry has one.
+type navItem struct {
+	key    string
+	label  string
+	screen func() tea.Model
+}
+
+var navItems = []navItem{
+	{key: "h", label: "Home", screen: homeScreen},
+	{key: "a", label: "Anime", screen: func() tea.Model { return listScreen("anime") }},
+	{key: "m", label: "Manga", screen: func() tea.Model { return listScreen("manga") }},
+	{key: "s", label: "Search"},
+	{key: "n", label: "Seasonal", screen: seasonalScreen},
+	{key: "g", label: "Rankings", screen: rankingsScreen},
+	{key: "d", label: "Schedule", screen: scheduleScreen},
+	{key: "t", label: "Stats", screen: statsScreen},
+	{key: "w", label: "Wrapped", screen: wrappedScreen},
+	{key: "e", label: "Suggested", screen: suggestedScreen},
+	{key: "c", label: "Community", screen: communityScreen},
+	{key: "p", label: "Profile", screen: profileScreen},
+	{key: "u", label: "Queue", screen: pendingScreen},
+	{key: "o", label: "Options"},
+	{key: "q", label: "Quit"},
+}
+
+// syncInterval is how often queued list updates are replayed against MAL
+const syncInterval = 30 * time.Second
+
+type syncTickMsg struct{}
+
+// pendingSyncedMsg is sent after the journal has been replayed. Scheduled is
+// set for the periodic replay, which schedules the next one when done.
+type pendingSyncedMsg struct {
+	result    lib.SyncResult
+	err       error
+	scheduled bool
+}
+
 func (s ScreenSwitcher) Init() tea.Cmd {
-	return s.currentScreen.Init()
+	return tea.Batch(s.currentScreen.Init(), scheduleSync())
 }
 
 func (s ScreenSwitcher) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -31,6 +81,30 @@ func (s ScreenSwitcher) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch m := msg.(type) {
 	case tea.WindowSizeMsg:
 		globals.width, globals.height = m.Width, m.Height
+		globals.imageRenderer.Resize()
+	case tea.KeyMsg:
+		if m.String() == "q" || m.String() == "ctrl+c" {
+			return s, tea.Quit
+		}
+		for _, item := range navItems {
+			if item.key == m.String() && item.screen != nil {
+				return s.Switch(item.screen())
+			}
+		}
+	case syncTickMsg:
+		return s, func() tea.Msg {
+			result, err := lib.SyncPending()
+			return pendingSyncedMsg{result: result, err: err, scheduled: true}
+		}
+	case listUpdatedMsg, plannedMsg:
+		refreshPendingCount()
+	case pendingSyncedMsg:
+		refreshPendingCount()
+		model, cmd = s.currentScreen.Update(msg)
+		if m.scheduled {
+			cmd = tea.Batch(cmd, scheduleSync())
+		}
+		return ScreenSwitcher{currentScreen: model}, cmd
 	}
 
 	model, cmd = s.currentScreen.Update(msg)
@@ -44,7 +118,113 @@ func (s ScreenSwitcher) View() string {
 
 func (s ScreenSwitcher) Switch(screen tea.Model) (tea.Model, tea.Cmd) {
 	s.currentScreen = screen
-	return s.currentScreen, s.currentScreen.Init()
+	return s, s.currentScreen.Init()
+}
+
+// coverLoadedMsg is sent once an image missing from the cache has been
+// downloaded, so the screen is drawn again with it
+type coverLoadedMsg struct{}
+
+// loadCover downloads an image that isn't cached yet in the background.
+// Views only draw images already on disk, so drawing never waits on the
+// network.
+func loadCover(mediaType string, malID int, size, url string) tea.Cmd {
+	if globals.imageCache == nil || url == "" || globals.imageCache.IsCached(mediaType, malID, size) {
+		return nil
+	}
+	return func() tea.Msg {
+		globals.imageCache.GetImage(mediaType, malID, size, url)
+		return coverLoadedMsg{}
+	}
+}
+
+func scheduleSync() tea.Cmd {
+	return tea.Tick(syncInterval, func(time.Time) tea.Msg {
+		return syncTickMsg{}
+	})
+}
+
+// syncPending replays queued list updates on request, forcing the ones
+// listed in force
+func syncPending(force ...string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := lib.SyncPending(force...)
+		return pendingSyncedMsg{result: result, err: err}
+	}
+}
+
+var barStyle = lipgloss.NewStyle().
+	Foreground(config.Colors.Text).
+	Background(config.Colors.Primary)
+
+// topBar renders the navigation bar shared by all screens
+func topBar() string {
+	w := lipgloss.Width
+
+	labels := make([]string, 0, len(navItems)+1)
+	labels = append(labels, config.PrettyAppName)
+	for _, item := range navItems {
+		labels = append(labels, navLabel(item))
+	}
+
+	mainText := barStyle.Padding(0, 0, 0, 1).Render(strings.Join(labels, " | "))
+	userName := "-"
+	if globals.CurrentUser != nil {
+		userName = globals.CurrentUser.Name
+	}
+	userText := barStyle.Padding(0, 1, 0, 0).Render("User: " + userName + " | [L]ogout")
+	separator := barStyle.Width(max(0, globals.width-w(mainText)-w(userText))).Render("")
+
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		mainText,
+		separator,
+		userText,
+	)
+}
+
+// navLabel brackets the first occurrence of the item's key in its label,
+// e.g. "Q[u]eue"
+func navLabel(item navItem) string {
+	i := strings.Index(strings.ToLower(item.label), item.key)
+	if i < 0 {
+		return "[" + strings.ToUpper(item.key) + "] " + item.label
+	}
+	return item.label[:i] + "[" + strings.ToUpper(item.label[i:i+1]) + "]" + item.label[i+1:]
+}
+
+// statusBar renders the bottom line shared by all screens, with badges for
+// offline mode and queued updates followed by the given messages
+func statusBar(messages ...string) string {
+	parts := make([]string, 0, len(messages)+2)
+	if lib.IsOffline() {
+		parts = append(parts, barStyle.Padding(0, 1).Render("offline"))
+	}
+	if globals.pendingCount > 0 {
+		parts = append(parts, barStyle.Padding(0, 1).Render(pluralize(globals.pendingCount, "pending change")))
+	}
+	for _, message := range messages {
+		if message != "" {
+			parts = append(parts, lipgloss.NewStyle().Faint(true).Render(message))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// refreshPendingCount rereads the number of queued list updates after the
+// journal changed
+func refreshPendingCount() {
+	if pending, err := lib.PendingUpdates(); err == nil {
+		globals.pendingCount = len(pending)
+	}
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
 }
 
 func screen() ScreenSwitcher {
@@ -68,6 +248,20 @@ func Initialize() tea.Model {
 	globals.height = height
 
 	globals.CurrentUser, _ = lib.CurrentUser()
+	if globals.CurrentUser != nil {
+		lib.RememberUser(globals.CurrentUser.ID)
+	}
+	globals.imageCache, _ = lib.NewImageCache()
+	globals.imageRenderer = lib.NewImageRenderer()
+	refreshPendingCount()
 
 	return screen()
 }
+
+// Shutdown persists state that is written lazily while the app runs. It
+// should be called once the program has exited.
+func Shutdown() {
+	if globals.imageCache != nil {
+		globals.imageCache.Flush()
+	}
+}
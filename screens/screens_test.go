@@ -0,0 +1,39 @@
+package screens
+
+import "testing"
+
+func TestNavLabel(t *testing.T) {
+	tests := []struct {
+		key, label, want string
+	}{
+		{"h", "Home", "[H]ome"},
+		{"s", "Seasonal", "[S]easonal"},
+		// The first occurrence of the key is marked, whatever its case
+		{"p", "Pending", "[P]ending"},
+		{"r", "Charts", "Cha[R]ts"},
+		{"x", "Stats", "[X] Stats"},
+	}
+
+	for _, tt := range tests {
+		if got := navLabel(navItem{key: tt.key, label: tt.label}); got != tt.want {
+			t.Errorf("navLabel(%q, %q) = %q, want %q", tt.key, tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0 pending changes"},
+		{1, "1 pending change"},
+		{2, "2 pending changes"},
+	}
+
+	for _, tt := range tests {
+		if got := pluralize(tt.n, "pending change"); got != tt.want {
+			t.Errorf("pluralize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package screens
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yato/config"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	seasonalSorts      = []string{"anime_score", "anime_num_list_users"}
+	seasonalMediaTypes = []string{"", "tv", "ona", "movie"}
+)
+
+// SeasonalScreen browses the anime of a season
+type SeasonalScreen struct {
+	year       int
+	season     string
+	sort       int
+	mediaType  int
+	hideListed bool
+	titles     []lib.Title
+	list       *lib.UserList
+	pending    []lib.PendingUpdate
+	added      map[int]bool
+	adding     map[int]bool
+	cursor     int
+	offset     int
+	loading    bool
+	message    string
+}
+
+type seasonalLoadedMsg struct {
+	year   int
+	season string
+	sort   string
+	titles []lib.Title
+	err    error
+}
+
+type plannedMsg struct {
+	malID int
+	title string
+	err   error
+}
+
+func seasonalScreen() tea.Model {
+	year, season := lib.CurrentSeason(time.Now())
+	screen := SeasonalScreen{year: year, season: season, added: map[int]bool{}, adding: map[int]bool{}, loading: true}
+
+	screen.list, _ = lib.LoadList("anime")
+	if screen.list == nil {
+		screen.list = &lib.UserList{MediaType: "anime"}
+	}
+	screen.pending, _ = lib.PendingUpdates()
+
+	return screen
+}
+
+func (s SeasonalScreen) Init() tea.Cmd {
+	return s.load()
+}
+
+func (s SeasonalScreen) load() tea.Cmd {
+	year, season, sort := s.year, s.season, seasonalSorts[s.sort]
+	return func() tea.Msg {
+		titles, err := lib.GetSeasonalAnime(year, season, sort)
+		return seasonalLoadedMsg{year: year, season: season, sort: sort, titles: titles, err: err}
+	}
+}
+
+func (s SeasonalScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		titles := s.visible()
+		switch msg.String() {
+		case "up", "k":
+			s.cursor--
+		case "down", "j":
+			s.cursor++
+		case "pgup":
+			s.cursor -= listPageSize()
+		case "pgdown":
+			s.cursor += listPageSize()
+		case "left", "right":
+			delta := -1
+			if msg.String() == "right" {
+				delta = 1
+			}
+			s.year, s.season = lib.ShiftSeason(s.year, s.season, delta)
+			s.cursor, s.offset, s.loading = 0, 0, true
+			return s, s.load()
+		case "1", "2":
+			s.sort = int(msg.String()[0] - '1')
+			s.loading = true
+			return s, s.load()
+		case "tab":
+			s.mediaType = (s.mediaType + 1) % len(seasonalMediaTypes)
+			s.cursor, s.offset = 0, 0
+		case ".":
+			s.hideListed = !s.hideListed
+			s.cursor, s.offset = 0, 0
+		case "+":
+			if len(titles) > 0 {
+				return s.planToWatch(titles[s.cursor])
+			}
+		}
+		s.scroll()
+	case seasonalLoadedMsg:
+		if msg.year != s.year || msg.season != s.season || msg.sort != seasonalSorts[s.sort] {
+			break
+		}
+		s.loading = false
+		s.titles = msg.titles
+		if msg.err != nil {
+			s.message = msg.err.Error()
+		}
+		s.scroll()
+	case plannedMsg:
+		delete(s.adding, msg.malID)
+		if errors.Is(msg.err, lib.ErrUpdateQueued) {
+			s.added[msg.malID] = true
+			s.message = "Saved offline, " + msg.title + " will be added when back online"
+		} else if msg.err != nil {
+			s.message = "Failed to add " + msg.title + ": " + msg.err.Error()
+		} else {
+			s.added[msg.malID] = true
+			s.message = "Added " + msg.title + " to Plan to Watch"
+		}
+		s.pending, _ = lib.PendingUpdates()
+		return s, syncList("anime")
+	case listSyncedMsg:
+		if msg.mediaType == "anime" && msg.list != nil {
+			s.list = msg.list
+		}
+	case pendingSyncedMsg:
+		s.pending, _ = lib.PendingUpdates()
+	}
+
+	return s, nil
+}
+
+func (s SeasonalScreen) planToWatch(title lib.Title) (tea.Model, tea.Cmd) {
+	if s.onList(title.ID) {
+		s.message = title.Title + " is already on your list"
+		return s, nil
+	}
+	if s.adding[title.ID] {
+		return s, nil
+	}
+
+	s.adding[title.ID] = true
+	update := lib.ListUpdate{
+		MediaType: "anime",
+		MALID:     title.ID,
+		Title:     title.Title,
+		Fields:    map[string]string{"status": "plan_to_watch"},
+	}
+
+	return s, func() tea.Msg {
+		_, err := lib.SubmitListUpdate(update)
+		return plannedMsg{malID: title.ID, title: title.Title, err: err}
+	}
+}
+
+// onList reports whether a title is on the user's list, including titles
+// added during this session or still waiting to be synced
+func (s SeasonalScreen) onList(malID int) bool {
+	if s.added[malID] || s.list.Find(malID) != nil {
+		return true
+	}
+	for _, p := range s.pending {
+		if p.Update.MediaType == "anime" && p.Update.MALID == malID {
+			return true
+		}
+	}
+	return false
+}
+
+// visible returns the titles matching the media type and list filters
+func (s SeasonalScreen) visible() []lib.Title {
+	titles := make([]lib.Title, 0, len(s.titles))
+	for _, title := range s.titles {
+		if mediaType := seasonalMediaTypes[s.mediaType]; mediaType != "" && title.MediaType != mediaType {
+			continue
+		}
+		if s.hideListed && s.onList(title.ID) {
+			continue
+		}
+		titles = append(titles, title)
+	}
+	return titles
+}
+
+func (s *SeasonalScreen) scroll() {
+	count := len(s.visible())
+	s.cursor = max(0, min(s.cursor, count-1))
+
+	rows := listPageSize()
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	} else if s.cursor >= s.offset+rows {
+		s.offset = s.cursor - rows + 1
+	}
+	s.offset = max(0, min(s.offset, count-rows))
+}
+
+func (s SeasonalScreen) View() string {
+	selectedStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Bold(true)
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	listedStyle := lipgloss.NewStyle().Faint(true)
+
+	sortLabel := "score"
+	if seasonalSorts[s.sort] == "anime_num_list_users" {
+		sortLabel = "members"
+	}
+	mediaLabel := "all types"
+	if mediaType := seasonalMediaTypes[s.mediaType]; mediaType != "" {
+		mediaLabel = strings.ToUpper(mediaType)
+	}
+	heading := fmt.Sprintf("%s %d  ·  sorted by %s  ·  %s", statusLabel(s.season), s.year, sortLabel, mediaLabel)
+	if s.hideListed {
+		heading += "  ·  hiding titles on your list"
+	}
+
+	titles := s.visible()
+	titleWidth := max(20, globals.width-36)
+
+	var content strings.Builder
+	content.WriteString(headerStyle.Render(heading) + "\n")
+	content.WriteString(headerStyle.Render(fmt.Sprintf("  %-*s %-6s %-6s %-9s %s", titleWidth, "Title", "Type", "Score", "Members", "")) + "\n")
+
+	end := min(len(titles), s.offset+listPageSize())
+	for i := s.offset; i < end; i++ {
+		title := titles[i]
+		listed := ""
+		if s.onList(title.ID) {
+			listed = "on list"
+		}
+
+		line := fmt.Sprintf("%-*s %-6s %-6s %-9d %s", titleWidth, truncate(title.Title, titleWidth),
+			strings.ToUpper(title.MediaType), formatMean(title.Mean), title.NumListUsers, listed)
+		switch {
+		case i == s.cursor:
+			line = selectedStyle.Render("> " + line)
+		case listed != "":
+			line = listedStyle.Render("  " + line)
+		default:
+			line = "  " + line
+		}
+		content.WriteString(line + "\n")
+	}
+
+	if s.loading {
+		content.WriteString("Loading...\n")
+	} else if len(titles) == 0 {
+		content.WriteString("Nothing here.\n")
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		content.String(),
+		"[←/→] season  [1] by score  [2] by members  [tab] type  [.] hide listed  [+] plan to watch",
+		statusBar(s.message),
+	)
+}
+
+// formatMean renders a MAL mean score, which is 0 for unscored titles
+func formatMean(mean float64) string {
+	if mean == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", mean)
+}
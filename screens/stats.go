@@ -0,0 +1,165 @@
+package screens
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statsChartLimit is how many buckets the breakdowns show
+const statsChartLimit = 12
+
+// StatsScreen charts analytics computed from the local lists
+type StatsScreen struct {
+	mediaType string
+	bySeason  bool
+	stats     map[string]lib.ListStats
+	offset    int
+}
+
+func statsScreen() tea.Model {
+	screen := StatsScreen{mediaType: "anime", stats: map[string]lib.ListStats{}}
+	for _, mediaType := range []string{"anime", "manga"} {
+		list, _ := lib.LoadList(mediaType)
+		if list == nil {
+			list = &lib.UserList{MediaType: mediaType}
+		}
+		screen.stats[mediaType] = lib.ComputeStats(list)
+	}
+	return screen
+}
+
+func (s StatsScreen) Init() tea.Cmd {
+	return tea.Batch(syncList("anime"), syncList("manga"))
+}
+
+func (s StatsScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			s.offset--
+		case "down", "j":
+			s.offset++
+		case "pgup":
+			s.offset -= s.rows()
+		case "pgdown":
+			s.offset += s.rows()
+		case "tab":
+			if s.mediaType == "anime" {
+				s.mediaType = "manga"
+			} else {
+				s.mediaType = "anime"
+			}
+			s.offset = 0
+		case "y":
+			s.bySeason = !s.bySeason
+		}
+		s.offset = max(0, min(s.offset, len(s.lines())-s.rows()))
+	case listSyncedMsg:
+		if msg.list != nil {
+			s.stats[msg.mediaType] = lib.ComputeStats(msg.list)
+		}
+	}
+
+	return s, nil
+}
+
+// rows returns how many chart lines fit between the bars
+func (s StatsScreen) rows() int {
+	return max(1, globals.height-4)
+}
+
+func (s StatsScreen) lines() []string {
+	return strings.Split(strings.TrimRight(s.render(), "\n"), "\n")
+}
+
+func (s StatsScreen) render() string {
+	headerStyle := lipgloss.NewStyle().Bold(true).MarginTop(1)
+	stats := s.stats[s.mediaType]
+	width := min(globals.width, 100)
+	count := func(v float64) string { return strconv.Itoa(int(v)) }
+
+	var b strings.Builder
+	section := func(title string) {
+		b.WriteString(headerStyle.Render(title) + "\n")
+	}
+
+	section("Score distribution")
+	labels := make([]string, len(stats.Scores))
+	for i := range labels {
+		labels[i] = strconv.Itoa(i + 1)
+	}
+	b.WriteString(histogram(stats.Scores[:], labels, 8, 5))
+
+	section("Your scores vs. MAL")
+	if len(stats.Differences) == 0 {
+		b.WriteString("No scored titles yet.\n")
+	} else {
+		fmt.Fprintf(&b, "Your mean %.2f, MAL mean of the same titles %.2f (%+.2f)\n",
+			stats.MeanScore, stats.MALMean, stats.MeanScore-stats.MALMean)
+		// The titles rated furthest above and below MAL
+		above := stats.Differences[:min(3, len(stats.Differences))]
+		below := stats.Differences[max(len(above), len(stats.Differences)-3):]
+		rows := append(append([]lib.ScoreDifference{}, above...), below...)
+		for i, d := range rows {
+			if i == len(above) && len(above)+len(below) < len(stats.Differences) {
+				b.WriteString("  ...\n")
+			}
+			fmt.Fprintf(&b, "  %+5.2f  %s (%d vs. %.2f)\n", d.Difference(), d.Title, d.Score, d.Mean)
+		}
+	}
+
+	section("Completion rate")
+	completion := make([]lib.Count, len(stats.Completion))
+	for i, c := range stats.Completion {
+		label := fmt.Sprintf("%s (%d/%d)", strings.ToUpper(c.MediaType), c.Completed, c.Started)
+		completion[i] = lib.Count{Label: label, Value: c.Rate() * 100}
+	}
+	b.WriteString(barChart(completion, 100, width, func(v float64) string { return fmt.Sprintf("%.0f%%", v) }))
+
+	section("Top genres")
+	b.WriteString(barChart(stats.Genres[:min(statsChartLimit, len(stats.Genres))], 0, width, count))
+
+	if s.mediaType == "anime" {
+		section("Top studios")
+		b.WriteString(barChart(stats.Studios[:min(statsChartLimit, len(stats.Studios))], 0, width, count))
+	}
+
+	activity, unit := stats.ActivityByYear, func(v float64) string { return fmt.Sprintf("%.0f ch", v) }
+	title := "Chapters read per year"
+	if s.mediaType == "anime" {
+		unit = func(v float64) string { return fmt.Sprintf("%.0fh", v) }
+		title = "Watch time per year"
+		if s.bySeason {
+			activity, title = stats.ActivityBySeason, "Watch time per season"
+		}
+	}
+	section(title)
+	b.WriteString(barChart(activity[max(0, len(activity)-statsChartLimit):], 0, width, unit))
+
+	return b.String()
+}
+
+func (s StatsScreen) View() string {
+	lines := s.lines()
+	offset := max(0, min(s.offset, len(lines)-s.rows()))
+	end := min(len(lines), offset+s.rows())
+
+	help := "[tab] anime/manga  [j/k] scroll"
+	if s.mediaType == "anime" {
+		help += "  [y] per year/season"
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		strings.Join(lines[offset:end], "\n"),
+		help,
+		statusBar(),
+	)
+}
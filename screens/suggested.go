@@ -0,0 +1,156 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+	"yato/config"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SuggestedScreen lists personalized recommendations based on the titles
+// the user scored highest
+type SuggestedScreen struct {
+	mediaType   string
+	suggestions map[string][]lib.Suggestion
+	loaded      map[string]bool
+	loading     map[string]bool
+	cursor      int
+	offset      int
+	message     string
+}
+
+type suggestionsLoadedMsg struct {
+	mediaType   string
+	suggestions []lib.Suggestion
+	err         error
+}
+
+func suggestedScreen() tea.Model {
+	return SuggestedScreen{
+		mediaType:   "anime",
+		suggestions: map[string][]lib.Suggestion{},
+		loaded:      map[string]bool{},
+		loading:     map[string]bool{},
+	}
+}
+
+func (s SuggestedScreen) Init() tea.Cmd {
+	return s.load()
+}
+
+func (s SuggestedScreen) load() tea.Cmd {
+	if s.loaded[s.mediaType] || s.loading[s.mediaType] {
+		return nil
+	}
+	s.loading[s.mediaType] = true
+
+	mediaType := s.mediaType
+	return func() tea.Msg {
+		list, err := lib.LoadList(mediaType)
+		if err != nil {
+			return suggestionsLoadedMsg{mediaType: mediaType, err: err}
+		}
+		suggestions, err := lib.SuggestTitles(list)
+		return suggestionsLoadedMsg{mediaType: mediaType, suggestions: suggestions, err: err}
+	}
+}
+
+func (s SuggestedScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			s.cursor--
+		case "down", "j":
+			s.cursor++
+		case "pgup":
+			s.cursor -= listPageSize()
+		case "pgdown":
+			s.cursor += listPageSize()
+		case "tab":
+			if s.mediaType == "anime" {
+				s.mediaType = "manga"
+			} else {
+				s.mediaType = "anime"
+			}
+			s.cursor, s.offset, s.message = 0, 0, ""
+			return s, s.load()
+		case "r":
+			s.message = ""
+			return s, s.load()
+		}
+		s.scroll()
+	case suggestionsLoadedMsg:
+		// Failed loads show what was gathered and are retried on [r]
+		s.loaded[msg.mediaType] = msg.err == nil
+		s.loading[msg.mediaType] = false
+		s.suggestions[msg.mediaType] = msg.suggestions
+		if msg.err != nil && msg.mediaType == s.mediaType {
+			s.message = "Some recommendations couldn't be loaded: " + msg.err.Error()
+		}
+		s.scroll()
+	}
+
+	return s, nil
+}
+
+func (s *SuggestedScreen) scroll() {
+	count := len(s.suggestions[s.mediaType])
+	s.cursor = max(0, min(s.cursor, count-1))
+
+	rows := listPageSize()
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	} else if s.cursor >= s.offset+rows {
+		s.offset = s.cursor - rows + 1
+	}
+	s.offset = max(0, min(s.offset, count-rows))
+}
+
+func (s SuggestedScreen) View() string {
+	selectedStyle := lipgloss.NewStyle().Foreground(config.Colors.Primary).Bold(true)
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	faint := lipgloss.NewStyle().Faint(true)
+
+	suggestions := s.suggestions[s.mediaType]
+	titleWidth := max(20, min(50, globals.width-40))
+	sourcesWidth := max(10, globals.width-titleWidth-16)
+
+	var content strings.Builder
+	content.WriteString(headerStyle.Render("Suggested "+s.mediaType+" based on your highest scored titles") + "\n")
+
+	end := min(len(suggestions), s.offset+listPageSize())
+	for i := s.offset; i < end; i++ {
+		suggestion := suggestions[i]
+		line := fmt.Sprintf("%-*s %6d votes  ", titleWidth, truncate(suggestion.Entry.Title, titleWidth), suggestion.Votes)
+		because := truncate("because you liked "+strings.Join(suggestion.Sources, ", "), sourcesWidth)
+		if i == s.cursor {
+			line = selectedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line + faint.Render(because) + "\n")
+	}
+
+	if s.loading[s.mediaType] {
+		content.WriteString("Gathering recommendations for your favorites...\n")
+	} else if len(suggestions) == 0 && s.message == "" {
+		content.WriteString("Score some completed titles to get suggestions.\n")
+	} else if len(suggestions) > 0 {
+		// Sources get cut off in the list, so spell them out for the selection
+		selected := suggestions[s.cursor]
+		content.WriteString("\n" + lipgloss.NewStyle().Width(max(20, globals.width-2)).Render(
+			fmt.Sprintf("%s was recommended to fans of %s.", selected.Entry.Title, strings.Join(selected.Sources, ", "))) + "\n")
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		content.String(),
+		"[tab] anime/manga  [r] retry",
+		statusBar(s.message),
+	)
+}
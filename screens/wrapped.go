@@ -0,0 +1,125 @@
+package screens
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"yato/config"
+	"yato/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// WrappedScreen shows the year in review of the user's lists
+type WrappedScreen struct {
+	year   int
+	lists  map[string]*lib.UserList
+	offset int
+}
+
+func wrappedScreen() tea.Model {
+	screen := WrappedScreen{year: time.Now().Year(), lists: map[string]*lib.UserList{}}
+	for _, mediaType := range []string{"anime", "manga"} {
+		list, _ := lib.LoadList(mediaType)
+		if list == nil {
+			list = &lib.UserList{MediaType: mediaType}
+		}
+		screen.lists[mediaType] = list
+	}
+	return screen
+}
+
+func (w WrappedScreen) Init() tea.Cmd {
+	return tea.Batch(syncList("anime"), syncList("manga"))
+}
+
+func (w WrappedScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			w.offset--
+		case "down", "j":
+			w.offset++
+		case "left":
+			w.year--
+			w.offset = 0
+		case "right":
+			w.year = min(w.year+1, time.Now().Year())
+			w.offset = 0
+		}
+		w.offset = max(0, min(w.offset, len(w.lines())-w.rows()))
+	case listSyncedMsg:
+		if msg.list != nil {
+			w.lists[msg.mediaType] = msg.list
+		}
+	}
+
+	return w, nil
+}
+
+func (w WrappedScreen) rows() int {
+	return max(1, globals.height-4)
+}
+
+func (w WrappedScreen) lines() []string {
+	return strings.Split(strings.TrimRight(w.render(), "\n"), "\n")
+}
+
+func (w WrappedScreen) render() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(config.Colors.Primary)
+	headerStyle := lipgloss.NewStyle().Bold(true).MarginTop(1)
+	wrapped := lib.ComputeWrapped(w.year, w.lists["anime"], w.lists["manga"])
+	width := min(globals.width, 100)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("%d in review", w.year)) + "\n")
+	fmt.Fprintf(&b, "%d anime completed, %d episodes, %.0f hours\n",
+		wrapped.CompletedAnime, wrapped.EpisodesWatched, wrapped.HoursWatched)
+	fmt.Fprintf(&b, "%d manga completed, %d chapters\n", wrapped.CompletedManga, wrapped.ChaptersRead)
+
+	if len(wrapped.TopGenres) > 0 {
+		b.WriteString(headerStyle.Render("Top genres") + "\n")
+		b.WriteString(barChart(wrapped.TopGenres, 0, width, func(v float64) string { return strconv.Itoa(int(v)) }))
+	}
+
+	if len(wrapped.TopRated) > 0 {
+		b.WriteString(headerStyle.Render("Highest rated") + "\n")
+		for _, entry := range wrapped.TopRated {
+			fmt.Fprintf(&b, "%2d/10  %s\n", entry.ListStatus.Score, entry.Node.Title)
+		}
+	}
+
+	if len(wrapped.Disagreements) > 0 {
+		b.WriteString(headerStyle.Render("Biggest disagreements with MAL") + "\n")
+		for _, d := range wrapped.Disagreements {
+			fmt.Fprintf(&b, "%+5.2f  %s (you %d, MAL %.2f)\n", d.Difference(), d.Title, d.Score, d.Mean)
+		}
+	}
+
+	b.WriteString(headerStyle.Render("Completed") + "\n")
+	if len(wrapped.Completed) == 0 {
+		b.WriteString("Nothing completed this year.\n")
+	}
+	for _, entry := range wrapped.Completed {
+		b.WriteString(entry.Node.Title + "\n")
+	}
+
+	return b.String()
+}
+
+func (w WrappedScreen) View() string {
+	lines := w.lines()
+	offset := max(0, min(w.offset, len(lines)-w.rows()))
+	end := min(len(lines), offset+w.rows())
+
+	return lipgloss.JoinVertical(
+		lipgloss.Top,
+		topBar(),
+		strings.Join(lines[offset:end], "\n"),
+		"[←/→] year  [j/k] scroll",
+		statusBar(),
+	)
+}